@@ -0,0 +1,239 @@
+// signer - утилита офлайн подписи переводов холодных кошельков.
+// Запускается на машине без сети: seed фраза хранится только здесь,
+// зашифрованная ключом из SIGNER_ENCRYPTION_KEY (32 байта).
+//
+//	signer keygen -keystore cold.json -wallet-type V5R1Final -network mainnet
+//	signer import -keystore cold.json -wallet-type V5R1Final -network mainnet < mnemonic.txt
+//	signer sign -keystore cold.json -in transfer.json -out signed.json
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"wallet_test/src/modules/wallet/service"
+)
+
+const encryptionKeyEnv = "SIGNER_ENCRYPTION_KEY"
+
+// keystore - файл с зашифрованной seed фразой холодного кошелька
+type keystore struct {
+	PublicKey     string `json:"public_key"` // ed25519, hex
+	EncryptedSeed string `json:"encrypted_seed"`
+}
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "keygen":
+		err = keygen(os.Args[2:], false)
+	case "import":
+		err = keygen(os.Args[2:], true)
+	case "sign":
+		err = sign(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: signer keygen|import|sign [flags]")
+	fmt.Fprintln(os.Stderr, "  keygen  создать seed фразу и сохранить ее в keystore")
+	fmt.Fprintln(os.Stderr, "  import  сохранить в keystore seed фразу из stdin")
+	fmt.Fprintln(os.Stderr, "  sign    подписать перевод из POST /api/v1/wallet/{id}/offline/transfers")
+	os.Exit(2)
+}
+
+// keygen создает (или импортирует из stdin) seed фразу, шифрует ее и
+// печатает публичный ключ и адрес для регистрации холодного кошелька
+func keygen(args []string, importSeed bool) error {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	path := fs.String("keystore", "keystore.json", "файл keystore")
+	walletType := fs.String("wallet-type", service.WalletTypeV5R1Final, "версия кошелька: V5R1Final, V4R2 или V3R2")
+	network := fs.String("network", service.NetworkMainnet, "сеть: mainnet или testnet")
+	_ = fs.Parse(args)
+
+	encryptionKey, err := loadEncryptionKey()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(*path); err == nil {
+		return fmt.Errorf("keystore %s already exists", *path)
+	}
+
+	seed := &service.Seed{Words: wallet.NewSeed()}
+	if importSeed {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("failed to read mnemonic: %w", err)
+		}
+		seed.Words = strings.Fields(line)
+		if len(seed.Words) != 24 {
+			return fmt.Errorf("expected 24 words, got %d", len(seed.Words))
+		}
+	}
+
+	publicKey, err := service.PublicKeyFromSeed(seed)
+	if err != nil {
+		return err
+	}
+
+	key, err := service.ParsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	addr, err := service.ColdWalletAddress(key, *walletType, *network)
+	if err != nil {
+		return err
+	}
+
+	encryptedSeed, err := service.EncryptSeed(strings.Join(seed.Words, " "), encryptionKey)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt seed: %w", err)
+	}
+
+	if err := writeJSON(*path, &keystore{PublicKey: publicKey, EncryptedSeed: encryptedSeed}); err != nil {
+		return err
+	}
+
+	if !importSeed {
+		fmt.Fprintln(os.Stderr, "Запишите seed фразу, она не выводится повторно:")
+		fmt.Fprintln(os.Stderr, strings.Join(seed.Words, " "))
+	}
+	fmt.Printf("public_key:  %s\n", publicKey)
+	fmt.Printf("address:     %s\n", addr.String())
+	fmt.Printf("wallet_type: %s\n", *walletType)
+	fmt.Printf("network:     %s\n", *network)
+
+	return nil
+}
+
+// sign проверяет перевод, показывает его содержимое и после подтверждения
+// записывает перевод с подписанным сообщением в boc
+func sign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	path := fs.String("keystore", "keystore.json", "файл keystore")
+	in := fs.String("in", "transfer.json", "неподписанный перевод")
+	out := fs.String("out", "signed.json", "файл для подписанного перевода")
+	yes := fs.Bool("yes", false, "подписать без подтверждения")
+	_ = fs.Parse(args)
+
+	encryptionKey, err := loadEncryptionKey()
+	if err != nil {
+		return err
+	}
+
+	var ks keystore
+	if err := readJSON(*path, &ks); err != nil {
+		return err
+	}
+
+	var transfer service.OfflineTransfer
+	if err := readJSON(*in, &transfer); err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(ks.PublicKey, transfer.PublicKey) {
+		return fmt.Errorf("transfer is for public key %s, keystore has %s", transfer.PublicKey, ks.PublicKey)
+	}
+
+	// Сообщения сверяются с описанием до показа: оператор подтверждает то, что подписывает
+	if err := service.VerifyOfflineMessages(&transfer); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Wallet:      %s (%s, %s)\n", transfer.Address, transfer.WalletType, transfer.Network)
+	fmt.Fprintf(os.Stderr, "Seqno:       %d\n", transfer.Seqno)
+	fmt.Fprintf(os.Stderr, "Valid until: %s\n", time.Unix(transfer.ValidUntil, 0).UTC().Format(time.RFC3339))
+	if transfer.Deploy {
+		fmt.Fprintln(os.Stderr, "Deploy:      wallet contract will be deployed")
+	}
+	for i, msg := range transfer.Messages {
+		fmt.Fprintf(os.Stderr, "#%d  %s TON -> %s (mode %d, bounce %t)", i, msg.Amount, msg.Recipient, msg.Mode, msg.Bounce)
+		if msg.Comment != "" {
+			fmt.Fprintf(os.Stderr, " %q", msg.Comment)
+		}
+		fmt.Fprintln(os.Stderr)
+		for _, detail := range msg.Details() {
+			fmt.Fprintf(os.Stderr, "    %s\n", detail)
+		}
+	}
+
+	if !*yes && !confirm("Sign this transfer?") {
+		return errors.New("signing cancelled")
+	}
+
+	phrase, err := service.DecryptSeed(ks.EncryptedSeed, encryptionKey)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt seed: %w", err)
+	}
+
+	if err := service.SignOfflineTransfer(&transfer, &service.Seed{Words: strings.Fields(phrase)}); err != nil {
+		return err
+	}
+
+	if err := writeJSON(*out, &transfer); err != nil {
+		return err
+	}
+
+	fmt.Printf("msg_hash: %s\n", transfer.MsgHash)
+	fmt.Printf("hash:     %s\n", transfer.Hash)
+	fmt.Printf("Отправьте %s в POST /api/v1/wallet/%d/offline/broadcast\n", *out, transfer.WalletID)
+
+	return nil
+}
+
+// loadEncryptionKey возвращает ключ шифрования keystore из окружения
+func loadEncryptionKey() (string, error) {
+	key := os.Getenv(encryptionKeyEnv)
+	if len(key) != 32 {
+		return "", fmt.Errorf("%s must be set to a 32-byte key", encryptionKeyEnv)
+	}
+	return key, nil
+}
+
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
@@ -50,9 +50,29 @@ echo "GET $BASE_URL/api/v1/wallet/list?user_id=1"
 curl -X GET "$BASE_URL/api/v1/wallet/list?user_id=1"
 echo -e "\n"
 
-# 6. Удалить кошелек (ID=1) - раскомментируйте при необходимости
+# 6. Webhook подписка на события кошельков
+# Для локальной проверки подойдет любой HTTP сервер, принимающий POST на http://localhost:9000/webhook
+echo "=========================================="
+echo "6. Создать webhook подписку"
+echo "POST $BASE_URL/api/v1/webhooks"
+curl -X POST "$BASE_URL/api/v1/webhooks" \
+  -H "Content-Type: application/json" \
+  -d '{
+    "url": "http://localhost:9000/webhook",
+    "events": ["deposit.received", "send.confirmed", "send.failed"]
+  }'
+echo -e "\n"
+
+# 7. Журнал доставок webhook подписки (ID=1)
+echo "=========================================="
+echo "7. Журнал доставок webhook подписки (ID=1)"
+echo "GET $BASE_URL/api/v1/webhooks/1/deliveries"
+curl -X GET "$BASE_URL/api/v1/webhooks/1/deliveries"
+echo -e "\n"
+
+# 8. Удалить кошелек (ID=1) - раскомментируйте при необходимости
 # echo "=========================================="
-# echo "6. Удалить кошелек (ID=1)"
+# echo "8. Удалить кошелек (ID=1)"
 # echo "DELETE $BASE_URL/api/v1/wallet/1"
 # curl -X DELETE "$BASE_URL/api/v1/wallet/1"
 # echo -e "\n"
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/uptrace/bun"
@@ -30,7 +31,7 @@ type Env struct {
 	JWT_Expired        int64  `env:"JWT_EXPIRED"`
 	JWT_RefreshExpired int64  `env:"JWT_REFRESH_EXPIRED"`
 	REDIS_Addr         string `env:"REDIS_ADDR"`
-	TON_Network        string `env:"TON_NETWORK" default:"testnet"` // mainnet или testnet
+	TON_Network        string `env:"TON_NETWORK" default:"testnet"` // mainnet, testnet или mainnet,testnet
 	ENCRYPTION_KEY     string `env:"ENCRYPTION_KEY"`                // Ключ для шифрования seed фраз (32 байта)
 }
 
@@ -119,6 +120,10 @@ func migration(db *bun.DB) error {
 		(*walletModel.User)(nil),
 		(*walletModel.Wallet)(nil),
 		(*walletModel.Transaction)(nil),
+		(*walletModel.HighloadQuery)(nil),
+		(*walletModel.Deposit)(nil),
+		(*walletModel.WebhookSubscription)(nil),
+		(*walletModel.WebhookDelivery)(nil),
 	}
 
 	for _, m := range models {
@@ -128,8 +133,59 @@ func migration(db *bun.DB) error {
 		}
 	}
 
-	// Create PostgreSQL functions
+	// Add columns introduced after the tables were created
 	_, err := db.ExecContext(ctx, `
+		ALTER TABLE wallets ADD COLUMN IF NOT EXISTS encrypted_password VARCHAR;
+		ALTER TABLE wallets ADD COLUMN IF NOT EXISTS is_watch_only BOOLEAN NOT NULL DEFAULT FALSE;
+		ALTER TABLE wallets ALTER COLUMN encrypted_seed DROP NOT NULL;
+		ALTER TABLE wallets ADD COLUMN IF NOT EXISTS subwallet_id BIGINT;
+		ALTER TABLE wallets ADD COLUMN IF NOT EXISTS highload_query_seq BIGINT NOT NULL DEFAULT 0;
+		CREATE INDEX IF NOT EXISTS highload_queries_pending_idx ON highload_queries (expires_at) WHERE status = 'pending';
+		ALTER TABLE transactions ALTER COLUMN tx_hash DROP NOT NULL;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS msg_hash VARCHAR;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS lt BIGINT;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS error VARCHAR;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp;
+		CREATE UNIQUE INDEX IF NOT EXISTS transactions_msg_hash_key ON transactions (msg_hash);
+		CREATE INDEX IF NOT EXISTS transactions_pending_idx ON transactions (wallet_id) WHERE status = 'pending';
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS direction VARCHAR;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tx_time TIMESTAMPTZ;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS jetton JSONB;
+		CREATE INDEX IF NOT EXISTS transactions_wallet_lt_idx ON transactions (wallet_id, lt DESC);
+		ALTER TABLE wallets ADD COLUMN IF NOT EXISTS last_indexed_lt BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE wallets ADD COLUMN IF NOT EXISTS history_lt BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE wallets ADD COLUMN IF NOT EXISTS history_hash VARCHAR;
+		ALTER TABLE wallets ADD COLUMN IF NOT EXISTS catchup_lt BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE wallets ADD COLUMN IF NOT EXISTS catchup_hash VARCHAR;
+		ALTER TABLE wallets ADD COLUMN IF NOT EXISTS catchup_top_lt BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS compute_exit_code INTEGER;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS action_result_code INTEGER;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS aborted BOOLEAN NOT NULL DEFAULT FALSE;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS bounced BOOLEAN NOT NULL DEFAULT FALSE;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS bounce_lt BIGINT;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS bounce_of VARCHAR;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS bounce_tx_hash VARCHAR;
+		CREATE INDEX IF NOT EXISTS transactions_unlinked_bounce_idx ON transactions (wallet_id) WHERE bounced AND bounce_of IS NULL;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS messages JSONB;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS net_amount VARCHAR;
+		ALTER TABLE wallets ADD COLUMN IF NOT EXISTS is_cold BOOLEAN NOT NULL DEFAULT FALSE;
+		CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+		CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, id DESC);
+		-- V5R1 кошельки testnet, созданные до разделения global_id по сетям, выведены
+		-- с MainnetGlobalID: у старых строк global_id заполняет BackfillNetworkGlobalIDs
+		ALTER TABLE wallets ADD COLUMN IF NOT EXISTS network_global_id INTEGER;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS seqno BIGINT;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS boc TEXT;
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS norm_hash VARCHAR;
+		CREATE INDEX IF NOT EXISTS transactions_norm_hash_idx ON transactions (norm_hash);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to alter tables: %w", err)
+	}
+
+	// Create PostgreSQL functions
+	_, err = db.ExecContext(ctx, `
 		CREATE EXTENSION IF NOT EXISTS pgcrypto;
 
 		CREATE OR REPLACE FUNCTION HASH_MAKE(password TEXT) RETURNS TEXT AS $$
@@ -157,5 +213,23 @@ func setupRoutes(router *gin.Engine, db *bun.DB, env *Env) {
 	})
 
 	// Wallet routes
-	wallet.Cmd(router, db, env.TON_Network, env.ENCRYPTION_KEY)
+	wallet.Cmd(router, db, tonNetworks(env.TON_Network), env.ENCRYPTION_KEY)
+}
+
+// tonNetworks разбирает TON_NETWORK: список сетей через запятую,
+// к каждой из которых сервис держит отдельный пул соединений
+func tonNetworks(value string) []string {
+	var networks []string
+	for _, network := range strings.Split(value, ",") {
+		network = strings.TrimSpace(network)
+		if network != "" {
+			networks = append(networks, network)
+		}
+	}
+
+	if len(networks) == 0 {
+		networks = []string{"testnet"}
+	}
+
+	return networks
 }
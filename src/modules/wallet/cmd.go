@@ -1,7 +1,9 @@
 package wallet_cmd
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/uptrace/bun"
@@ -18,12 +20,53 @@ import (
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
-func Cmd(router *gin.Engine, db *bun.DB, network string, encryptionKey string) {
-	walletService, err := service.NewWalletService(db, network, encryptionKey)
+func Cmd(router *gin.Engine, db *bun.DB, networks []string, encryptionKey string) {
+	webhookService := service.NewWebhookService(db)
+
+	walletService, err := service.NewWalletService(db, networks, encryptionKey, webhookService)
 	if err != nil {
 		log.Fatalf("Failed to create wallet service: %v", err)
 	}
 
+	// Приводим адреса кошельков к одной форме
+	updated, err := walletService.BackfillStoredAddresses(context.Background())
+	if err != nil {
+		log.Printf("Wallet address backfill finished with errors: %v", err)
+	}
+	if updated > 0 {
+		log.Printf("Wallet address normalized for %d wallets", updated)
+	}
+
+	// Заполняем public_key у кошельков, созданных до его сохранения
+	updated, err = walletService.BackfillPublicKeys(context.Background())
+	if err != nil {
+		log.Printf("Public key backfill finished with errors: %v", err)
+	}
+	if updated > 0 {
+		log.Printf("Public key backfilled for %d wallets", updated)
+	}
+
+	// Сохраняем global_id у V5R1 кошельков, созданных до его сохранения
+	updated, err = walletService.BackfillNetworkGlobalIDs(context.Background())
+	if err != nil {
+		log.Printf("Network global id backfill finished with errors: %v", err)
+	}
+	if updated > 0 {
+		log.Printf("Network global id backfilled for %d wallets", updated)
+	}
+
+	// Финализируем истекшие сообщения highload кошельков
+	go walletService.RunHighloadTracker(context.Background(), time.Minute)
+
+	// Подтверждаем асинхронные отправки по истории кошельков
+	go walletService.RunSendTracker(context.Background(), 10*time.Second)
+
+	// Сохраняем историю кошельков в таблицу transactions
+	go walletService.RunIndexer(context.Background(), 15*time.Second)
+
+	// Доставляем события на webhook подписки
+	go webhookService.RunDispatcher(context.Background(), 5*time.Second)
+
 	walletHandler := handler.NewWalletHandler(walletService)
 
 	walletGroup := router.Group("/api/v1/wallet")
@@ -31,6 +74,15 @@ func Cmd(router *gin.Engine, db *bun.DB, network string, encryptionKey string) {
 		// Создать кошелек
 		walletGroup.POST("", walletHandler.CreateWallet)
 
+		// Импортировать кошелек по мнемонике
+		walletGroup.POST("/import", walletHandler.ImportWallet)
+
+		// Добавить watch-only кошелек по адресу
+		walletGroup.POST("/watch", walletHandler.WatchWallet)
+
+		// Добавить холодный кошелек по публичному ключу
+		walletGroup.POST("/cold", walletHandler.RegisterColdWallet)
+
 		// Получить информацию о кошельке
 		walletGroup.GET("/:id", walletHandler.GetWalletInfo)
 
@@ -43,10 +95,90 @@ func Cmd(router *gin.Engine, db *bun.DB, network string, encryptionKey string) {
 		// Отправить TON монеты
 		walletGroup.POST("/:id/send", walletHandler.SendCoins)
 
+		// Оценить комиссию перевода без отправки
+		walletGroup.POST("/:id/send/estimate", walletHandler.EstimateSend)
+
+		// Статус отправки
+		walletGroup.GET("/:id/send/:send_id", walletHandler.GetSendStatus)
+
+		// Отправить TON нескольким получателям
+		walletGroup.POST("/:id/send-batch", walletHandler.SendBatch)
+
+		// Подготовить перевод холодного кошелька для офлайн подписи
+		walletGroup.POST("/:id/offline/transfers", walletHandler.PrepareOfflineTransfer)
+
+		// Отправить перевод, подписанный офлайн
+		walletGroup.POST("/:id/offline/broadcast", walletHandler.BroadcastOfflineTransfer)
+
+		// Сообщения highload кошелька
+		walletGroup.GET("/:id/highload/queries", walletHandler.ListHighloadQueries)
+
+		// Повторить отправку highload сообщения
+		walletGroup.POST("/:id/highload/queries/:query/retry", walletHandler.RetryHighloadQuery)
+
+		// Балансы жетонов кошелька
+		walletGroup.GET("/:id/jettons", walletHandler.GetJettonBalances)
+
+		// Отправить жетоны
+		walletGroup.POST("/:id/jettons/send", walletHandler.SendJetton)
+
+		// NFT кошелька
+		walletGroup.GET("/:id/nfts", walletHandler.ListNFTs)
+
+		// Информация об NFT кошелька
+		walletGroup.GET("/:id/nfts/:item", walletHandler.GetNFTItem)
+
+		// Передать NFT
+		walletGroup.POST("/:id/nfts/:item/transfer", walletHandler.TransferNFT)
+
 		// Список кошельков пользователя
 		walletGroup.GET("/list", walletHandler.ListUserWallets)
 
 		// Удалить кошелек
 		walletGroup.DELETE("/:id", walletHandler.DeleteWallet)
 	}
+
+	jettonGroup := router.Group("/api/v1/jettons")
+	{
+		// Информация о жетоне
+		jettonGroup.GET("/:master", walletHandler.GetJettonInfo)
+	}
+
+	// Отправить подписанное внешнее сообщение
+	router.POST("/api/v1/broadcast", walletHandler.Broadcast)
+
+	// Найти транзакцию по хешу транзакции или внешнего сообщения
+	router.GET("/api/v1/transactions/:hash", walletHandler.LookupTransaction)
+
+	// Состояние любого аккаунта
+	router.GET("/api/v1/accounts/:address", walletHandler.GetAccount)
+
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+
+	webhookGroup := router.Group("/api/v1/webhooks")
+	{
+		// Создать подписку
+		webhookGroup.POST("", webhookHandler.CreateSubscription)
+
+		// Список подписок
+		webhookGroup.GET("", webhookHandler.ListSubscriptions)
+
+		// Получить подписку
+		webhookGroup.GET("/:id", webhookHandler.GetSubscription)
+
+		// Изменить подписку
+		webhookGroup.PUT("/:id", webhookHandler.UpdateSubscription)
+
+		// Удалить подписку
+		webhookGroup.DELETE("/:id", webhookHandler.DeleteSubscription)
+
+		// Журнал доставок
+		webhookGroup.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+
+		// Повторить все неуспешные доставки
+		webhookGroup.POST("/:id/deliveries/replay", webhookHandler.ReplayFailed)
+
+		// Повторить доставку
+		webhookGroup.POST("/:id/deliveries/:delivery/replay", webhookHandler.ReplayDelivery)
+	}
 }
@@ -0,0 +1,28 @@
+package dto
+
+type GetAccountRequest struct {
+	Network string `form:"network" json:"network" binding:"required,oneof=mainnet testnet"` // Сеть
+}
+
+type AccountResponse struct {
+	Address       string `json:"address"`                // Bounceable форма адреса
+	NonBounceable string `json:"non_bounceable"`         // Non-bounceable форма адреса
+	RawAddress    string `json:"raw_address"`            // workchain:hex
+	Network       string `json:"network"`                // Сеть
+	Bounceable    bool   `json:"bounceable"`             // Флаг bounceable у запрошенного адреса
+	TestnetOnly   bool   `json:"testnet_only"`           // Флаг testnet у запрошенного адреса
+	Status        string `json:"status"`                 // uninit, active, frozen, nonexist
+	Deployed      bool   `json:"deployed"`               // Контракт развернут (active)
+	SendBounce    bool   `json:"send_bounce"`            // Рекомендуемый bounce флаг перевода на этот адрес
+	Balance       string `json:"balance"`                // Баланс в TON
+	LastTxLt      uint64 `json:"last_tx_lt"`             // lt последней транзакции
+	LastTxHash    string `json:"last_tx_hash,omitempty"` // Хеш последней транзакции (base64)
+	CodeHash      string `json:"code_hash,omitempty"`    // Хеш кода контракта (hex)
+	DataHash      string `json:"data_hash,omitempty"`    // Хеш данных контракта (hex)
+	FrozenHash    string `json:"frozen_hash,omitempty"`  // Хеш состояния frozen аккаунта (hex)
+	Interface     string `json:"interface,omitempty"`    // Версия кошелька по коду контракта
+	WalletType    string `json:"wallet_type,omitempty"`  // Тип кошелька, поддерживаемый сервисом
+	PublicKey     string `json:"public_key,omitempty"`   // Публичный ключ кошелька (hex)
+	Seqno         *int64 `json:"seqno,omitempty"`        // seqno кошелька
+	WalletID      int64  `json:"wallet_id,omitempty"`    // Кошелек сервиса с этим адресом
+}
@@ -0,0 +1,18 @@
+package dto
+
+type BroadcastRequest struct {
+	Boc     string `json:"boc" binding:"required"`                           // Подписанное внешнее сообщение, base64 BOC
+	Network string `json:"network" binding:"required,oneof=mainnet testnet"` // Сеть
+}
+
+type BroadcastResponse struct {
+	Hash        string `json:"hash"`                // Нормализованный хеш сообщения (hex, TEP-467)
+	MsgHash     string `json:"msg_hash"`            // Хеш тела сообщения (hex)
+	Destination string `json:"destination"`         // Адрес получателя сообщения
+	Network     string `json:"network"`             // Сеть
+	WalletID    int64  `json:"wallet_id,omitempty"` // Кошелек сервиса, если сообщение адресовано ему
+	SendID      int64  `json:"send_id,omitempty"`   // ID отправки для GET /api/v1/wallet/{id}/send/{send_id}
+	Status      string `json:"status,omitempty"`    // Статус отправки
+	ExpiresAt   string `json:"expires_at,omitempty"`
+	Error       string `json:"error,omitempty"` // Ошибка отправки: сообщение повторяется до expires_at, результат - по send_id
+}
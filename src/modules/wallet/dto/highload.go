@@ -0,0 +1,25 @@
+package dto
+
+type ListHighloadQueriesRequest struct {
+	Status string `form:"status" json:"status" binding:"omitempty,oneof=pending processed expired"`
+	Limit  int    `form:"limit" json:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
+type HighloadQueryDTO struct {
+	ID           int64  `json:"id"`             // ID записи
+	QueryID      int64  `json:"query_id"`       // query_id в контракте
+	MsgCreatedAt int64  `json:"msg_created_at"` // created_at внешнего сообщения (unix)
+	ExpiresAt    string `json:"expires_at"`     // После этого сообщение не будет принято
+	MsgHash      string `json:"msg_hash"`       // Хеш тела внешнего сообщения (hex)
+	Messages     int    `json:"messages"`       // Число внутренних сообщений
+	Status       string `json:"status"`         // pending, processed, expired
+	TxHash       string `json:"tx_hash,omitempty"`
+	Error        string `json:"error,omitempty"` // Последняя ошибка отправки
+	CreatedAt    string `json:"created_at"`
+}
+
+type ListHighloadQueriesResponse struct {
+	WalletID int64               `json:"wallet_id"`
+	Queries  []*HighloadQueryDTO `json:"queries"`
+	Total    int                 `json:"total"`
+}
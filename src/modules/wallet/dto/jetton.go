@@ -0,0 +1,75 @@
+package dto
+
+type GetJettonBalancesRequest struct {
+	Masters []string `form:"master" binding:"required,min=1,max=20"` // Адреса jetton master контрактов
+}
+
+type GetJettonInfoRequest struct {
+	Network string `form:"network" binding:"required,oneof=mainnet testnet"`
+}
+
+type JettonMetadataDTO struct {
+	URI         string `json:"uri,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Symbol      string `json:"symbol,omitempty"`
+	Image       string `json:"image,omitempty"`
+}
+
+type JettonInfoResponse struct {
+	Master      string             `json:"master"`
+	TotalSupply string             `json:"total_supply"` // в минимальных единицах
+	Mintable    bool               `json:"mintable"`
+	Admin       string             `json:"admin,omitempty"`
+	Decimals    int                `json:"decimals"`
+	Metadata    *JettonMetadataDTO `json:"metadata"`
+}
+
+type JettonBalanceDTO struct {
+	Master       string `json:"master"`
+	JettonWallet string `json:"jetton_wallet"` // Адрес jetton-кошелька владельца
+	Symbol       string `json:"symbol,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Decimals     int    `json:"decimals"`
+	Balance      string `json:"balance"`     // В минимальных единицах
+	BalanceUnits string `json:"balance_fmt"` // С учетом decimals
+}
+
+type GetJettonBalancesResponse struct {
+	WalletID int64               `json:"wallet_id"`
+	Address  string              `json:"address"`
+	Jettons  []*JettonBalanceDTO `json:"jettons"`
+	Total    int                 `json:"total"`
+}
+
+type SendJettonRequest struct {
+	Master              string `json:"master" binding:"required"`      // Адрес jetton master контракта
+	Recipient           string `json:"recipient" binding:"required"`   // Адрес получателя (владельца)
+	Amount              string `json:"amount" binding:"required"`      // Сумма в единицах жетона (например "10.5")
+	ForwardAmount       string `json:"forward_amount,omitempty"`       // TON для transfer_notification получателю
+	ForwardComment      string `json:"forward_comment,omitempty"`      // Комментарий в forward_payload
+	ResponseDestination string `json:"response_destination,omitempty"` // Куда вернуть излишек TON (по умолчанию отправитель)
+}
+
+type JettonTransferDTO struct {
+	Direction    string `json:"direction"` // "in" или "out"
+	QueryID      uint64 `json:"query_id"`
+	Amount       string `json:"amount"` // В минимальных единицах
+	Counterparty string `json:"counterparty"`
+	JettonWallet string `json:"jetton_wallet"`
+	Comment      string `json:"comment,omitempty"`
+}
+
+type SendJettonResponse struct {
+	ID           int64  `json:"id"`            // ID отправки для опроса статуса
+	Status       string `json:"status"`        // pending
+	MsgHash      string `json:"msg_hash"`      // Хеш тела внешнего сообщения (hex)
+	Address      string `json:"address"`       // Адрес отправителя
+	Master       string `json:"master"`        // Адрес jetton master контракта
+	JettonWallet string `json:"jetton_wallet"` // Jetton-кошелек отправителя
+	Amount       string `json:"amount"`        // Сумма жетонов
+	Recipient    string `json:"recipient"`     // Адрес получателя
+	Comment      string `json:"comment,omitempty"`
+	ExpiresAt    string `json:"expires_at"`      // Сообщение действительно до
+	Error        string `json:"error,omitempty"` // Ошибка отправки: сообщение повторяется до expires_at, результат - по ID
+}
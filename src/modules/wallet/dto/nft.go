@@ -0,0 +1,67 @@
+package dto
+
+type ListNFTsRequest struct {
+	Scan  int      `form:"scan" json:"scan" binding:"omitempty,min=1,max=1000"` // Сколько последних транзакций просмотреть (по умолчанию 100)
+	Items []string `form:"item" json:"item" binding:"omitempty,max=50"`         // Адреса NFT item для явной проверки
+}
+
+type NFTMetadataDTO struct {
+	URI         string `json:"uri,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+}
+
+type NFTCollectionDTO struct {
+	Address       string          `json:"address"`
+	Owner         string          `json:"owner,omitempty"`
+	NextItemIndex string          `json:"next_item_index"`
+	Metadata      *NFTMetadataDTO `json:"metadata,omitempty"`
+	MetadataError string          `json:"metadata_error,omitempty"`
+}
+
+type NFTItemDTO struct {
+	Address       string            `json:"address"`
+	Index         string            `json:"index"`
+	Owner         string            `json:"owner"`
+	Collection    *NFTCollectionDTO `json:"collection,omitempty"`
+	Metadata      *NFTMetadataDTO   `json:"metadata,omitempty"`
+	MetadataError string            `json:"metadata_error,omitempty"`
+}
+
+type NFTItemErrorDTO struct {
+	Item  string `json:"item"`  // Адрес кандидата
+	Error string `json:"error"` // Почему владение не проверено
+}
+
+type ListNFTsResponse struct {
+	WalletID        int64              `json:"wallet_id"`
+	Address         string             `json:"address"`
+	Items           []*NFTItemDTO      `json:"items"`
+	Total           int                `json:"total"`
+	Scanned         int                `json:"scanned"`          // Просмотрено транзакций
+	HistoryComplete bool               `json:"history_complete"` // false - более старые NFT можно найти через scan или item
+	Errors          []*NFTItemErrorDTO `json:"errors,omitempty"` // Кандидаты, которые не удалось проверить
+}
+
+type TransferNFTRequest struct {
+	NewOwner            string `json:"new_owner" binding:"required"`   // Адрес нового владельца
+	QueryID             uint64 `json:"query_id,omitempty"`             // query_id (по умолчанию случайный)
+	ForwardAmount       string `json:"forward_amount,omitempty"`       // TON для ownership_assigned новому владельцу
+	ForwardComment      string `json:"forward_comment,omitempty"`      // Комментарий в forward_payload
+	ResponseDestination string `json:"response_destination,omitempty"` // Куда вернуть излишек TON (по умолчанию отправитель)
+}
+
+type TransferNFTResponse struct {
+	ID        int64  `json:"id"`        // ID отправки для опроса статуса
+	Status    string `json:"status"`    // pending
+	MsgHash   string `json:"msg_hash"`  // Хеш тела внешнего сообщения (hex)
+	Address   string `json:"address"`   // Адрес отправителя
+	Item      string `json:"item"`      // Адрес NFT item
+	NewOwner  string `json:"new_owner"` // Адрес нового владельца
+	QueryID   uint64 `json:"query_id"`
+	Amount    string `json:"amount"` // TON, отправленные на item (forward_amount + газ)
+	Comment   string `json:"comment,omitempty"`
+	ExpiresAt string `json:"expires_at"`      // Сообщение действительно до
+	Error     string `json:"error,omitempty"` // Ошибка отправки: сообщение повторяется до expires_at, результат - по ID
+}
@@ -0,0 +1,47 @@
+package dto
+
+type RegisterColdWalletRequest struct {
+	UserID     int64  `json:"user_id" binding:"required"`
+	PublicKey  string `json:"public_key" binding:"required"` // ed25519 ключ из signer keygen, hex
+	Address    string `json:"address,omitempty"`             // Проверяется на совпадение с адресом по ключу
+	WalletType string `json:"wallet_type" binding:"required,oneof=V5R1Final V4R2 V3R2"`
+	Network    string `json:"network" binding:"required,oneof=mainnet testnet"`
+}
+
+type OfflineTransferRequest struct {
+	Messages []*SendCoinsRequest `json:"messages" binding:"required,min=1,max=255,dive"`     // Переводы одного внешнего сообщения
+	TTL      int                 `json:"ttl,omitempty" binding:"omitempty,min=60,max=86400"` // Срок действия в секундах (по умолчанию 3600)
+}
+
+// OfflineTransferDTO - перевод для подписи утилитой signer. Подписанный
+// результат можно отправить в POST /api/v1/wallet/{id}/offline/broadcast как есть.
+type OfflineTransferDTO struct {
+	Version    int                  `json:"version"`     // Версия формата
+	WalletID   int64                `json:"wallet_id"`   // ID кошелька
+	Address    string               `json:"address"`     // Адрес кошелька
+	PublicKey  string               `json:"public_key"`  // Ключ, которым нужно подписать (hex)
+	WalletType string               `json:"wallet_type"` // Версия кошелька
+	Network    string               `json:"network"`     // Сеть
+	Subwallet  uint32               `json:"subwallet"`   // subwallet кошелька
+	Seqno      uint32               `json:"seqno"`       // seqno на момент подготовки
+	ValidUntil int64                `json:"valid_until"` // Unix время, до которого сообщение принимается сетью
+	Deploy     bool                 `json:"deploy"`      // Кошелек не развернут: сообщение задеплоит его
+	Messages   []*OfflineMessageDTO `json:"messages"`    // Внутренние сообщения
+	Payload    string               `json:"payload"`     // Подписываемая часть тела, base64 BOC
+	ExpiresAt  string               `json:"expires_at"`  // valid_until в формате даты
+}
+
+type OfflineMessageDTO struct {
+	Mode      uint8  `json:"mode"`                 // Режим отправки
+	Recipient string `json:"recipient"`            // Адрес получателя
+	Amount    string `json:"amount"`               // Сумма в TON
+	Bounce    bool   `json:"bounce"`               // Bounce флаг
+	Comment   string `json:"comment,omitempty"`    // Комментарий
+	Payload   string `json:"payload,omitempty"`    // Тело, если это не комментарий, base64 BOC
+	StateInit string `json:"state_init,omitempty"` // State-init получателя, base64 BOC
+	Message   string `json:"message"`              // Внутреннее сообщение, base64 BOC
+}
+
+type OfflineBroadcastRequest struct {
+	Boc string `json:"boc" binding:"required"` // Подписанное внешнее сообщение из signer sign, base64 BOC
+}
@@ -0,0 +1,25 @@
+package dto
+
+type LookupTransactionRequest struct {
+	Address string `form:"address" json:"address"`                                           // Аккаунт транзакции: нужен, если ее нет в истории кошельков сервиса
+	Lt      uint64 `form:"lt" json:"lt"`                                                     // lt транзакции: ускоряет поиск в блокчейне
+	Network string `form:"network" json:"network" binding:"omitempty,oneof=mainnet testnet"` // Сеть аккаунта, обязательна вместе с address
+}
+
+type TransactionFeesDTO struct {
+	Total   string `json:"total"`    // total_fees транзакции
+	Import  string `json:"import"`   // Прием внешнего сообщения
+	Storage string `json:"storage"`  // Storage фаза
+	Compute string `json:"compute"`  // Газ compute фазы
+	Action  string `json:"action"`   // Action фаза
+	Forward string `json:"forward"`  // Пересылка исходящих сообщений
+	GasUsed int64  `json:"gas_used"` // Израсходованный газ
+}
+
+type LookupTransactionResponse struct {
+	Account     string          `json:"account"`             // Адрес аккаунта транзакции
+	Network     string          `json:"network"`             // Сеть
+	WalletID    int64           `json:"wallet_id,omitempty"` // Кошелек сервиса, если транзакция его
+	Source      string          `json:"source"`              // local - из истории сервиса, chain - из блокчейна
+	Transaction *TransactionDTO `json:"transaction"`
+}
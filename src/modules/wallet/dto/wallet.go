@@ -1,28 +1,63 @@
 package dto
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
 type CreateWalletRequest struct {
+	UserID      int64   `json:"user_id" binding:"required"`
+	WalletType  string  `json:"wallet_type" binding:"required,oneof=V5R1Final V4R2 V3R2 HighloadV3"`
+	Network     string  `json:"network" binding:"required,oneof=mainnet testnet"`
+	SubwalletID *uint32 `json:"subwallet_id,omitempty"` // Только для HighloadV3 (по умолчанию 698983191)
+}
+
+type ImportWalletRequest struct {
+	UserID           int64   `json:"user_id" binding:"required"`
+	Mnemonic         string  `json:"mnemonic" binding:"required"` // 24 слова через пробел
+	MnemonicPassword string  `json:"mnemonic_password,omitempty"` // Пароль мнемоники (если есть)
+	WalletType       string  `json:"wallet_type" binding:"required,oneof=V5R1Final V4R2 V3R2 HighloadV3"`
+	Network          string  `json:"network" binding:"required,oneof=mainnet testnet"`
+	SubwalletID      *uint32 `json:"subwallet_id,omitempty"` // Только для HighloadV3 (по умолчанию 698983191)
+}
+
+type WatchWalletRequest struct {
 	UserID     int64  `json:"user_id" binding:"required"`
-	WalletType string `json:"wallet_type" binding:"required,oneof=V5R1Final V4R2"`
+	Address    string `json:"address" binding:"required"`
+	WalletType string `json:"wallet_type,omitempty" binding:"omitempty,oneof=V5R1Final V4R2 V3R2 HighloadV3"` // Определяется по коду контракта, если он развернут
 	Network    string `json:"network" binding:"required,oneof=mainnet testnet"`
 }
 
 type CreateWalletResponse struct {
-	ID         int64  `json:"id"`
-	Address    string `json:"address"`
-	WalletType string `json:"wallet_type"`
-	Network    string `json:"network"`
-	CreatedAt  string `json:"created_at"`
+	ID          int64  `json:"id"`
+	Address     string `json:"address"`
+	PublicKey   string `json:"public_key"`
+	WalletType  string `json:"wallet_type"`
+	Network     string `json:"network"`
+	SubwalletID *int64 `json:"subwallet_id,omitempty"`
+	IsWatchOnly bool   `json:"is_watch_only"`
+	IsCold      bool   `json:"is_cold"` // Ключ только у офлайн подписи
+	CreatedAt   string `json:"created_at"`
 }
 
 type GetWalletInfoResponse struct {
-	ID         int64  `json:"id"`
-	Address    string `json:"address"`
-	Balance    string `json:"balance"`
-	WalletType string `json:"wallet_type"`
-	Network    string `json:"network"`
-	Seqno      int64  `json:"seqno"`
-	IsActive   bool   `json:"is_active"`
-	CreatedAt  string `json:"created_at"`
+	ID          int64  `json:"id"`
+	Address     string `json:"address"`
+	PublicKey   string `json:"public_key"`
+	Balance     string `json:"balance"`
+	WalletType  string `json:"wallet_type"`
+	Network     string `json:"network"`
+	Seqno       int64  `json:"seqno"`
+	Status      string `json:"status"`                 // uninit, active, frozen, nonexist
+	LastTxLt    uint64 `json:"last_tx_lt"`             // lt последней транзакции
+	LastTxHash  string `json:"last_tx_hash,omitempty"` // хеш последней транзакции (base64)
+	CodeHash    string `json:"code_hash,omitempty"`    // хеш кода контракта (hex)
+	SubwalletID *int64 `json:"subwallet_id,omitempty"` // subwallet_id (HighloadV3)
+	IsActive    bool   `json:"is_active"`
+	IsWatchOnly bool   `json:"is_watch_only"`
+	IsCold      bool   `json:"is_cold"` // Ключ только у офлайн подписи
+	CreatedAt   string `json:"created_at"`
 }
 
 type GetBalanceResponse struct {
@@ -36,12 +71,15 @@ type ListWalletsResponse struct {
 }
 
 type WalletSummary struct {
-	ID         int64  `json:"id"`
-	Address    string `json:"address"`
-	WalletType string `json:"wallet_type"`
-	Network    string `json:"network"`
-	IsActive   bool   `json:"is_active"`
-	CreatedAt  string `json:"created_at"`
+	ID          int64  `json:"id"`
+	Address     string `json:"address"`
+	PublicKey   string `json:"public_key"`
+	WalletType  string `json:"wallet_type"`
+	Network     string `json:"network"`
+	IsActive    bool   `json:"is_active"`
+	IsWatchOnly bool   `json:"is_watch_only"`
+	IsCold      bool   `json:"is_cold"` // Ключ только у офлайн подписи
+	CreatedAt   string `json:"created_at"`
 }
 
 type ErrorResponse struct {
@@ -57,7 +95,12 @@ type SuccessResponse struct {
 }
 
 type GetTransactionsRequest struct {
-	Limit int `form:"limit" json:"limit" binding:"omitempty,min=1,max=100"`
+	Limit     int       `form:"limit" json:"limit" binding:"omitempty,min=1,max=100"`
+	FromDate  time.Time `form:"from_date" json:"from_date"`                                                      // Не раньше (RFC3339)
+	ToDate    time.Time `form:"to_date" json:"to_date"`                                                          // Раньше (RFC3339)
+	Direction string    `form:"direction" json:"direction" binding:"omitempty,oneof=in out"`                     // Направление
+	Status    string    `form:"status" json:"status" binding:"omitempty,oneof=pending confirmed failed bounced"` // Статус
+	Cursor    string    `form:"cursor" json:"cursor"`                                                            // next_cursor предыдущей страницы
 }
 
 type TransactionDTO struct {
@@ -65,12 +108,40 @@ type TransactionDTO struct {
 	Lt        uint64 `json:"lt"`
 	Timestamp int64  `json:"timestamp"`
 	Type      string `json:"type"`
-	Amount    string `json:"amount"`
+	Amount    string `json:"amount"`               // Входящая сумма или сумма всех исходящих сообщений
+	NetAmount string `json:"net_amount,omitempty"` // Изменение баланса со знаком, с учетом комиссии
 	Fee       string `json:"fee"`
 	From      string `json:"from"`
-	To        string `json:"to"`
+	To        string `json:"to"` // Получатель первого исходящего сообщения
 	Comment   string `json:"comment,omitempty"`
 	Success   bool   `json:"success"`
+	Status    string `json:"status"`          // pending, confirmed, failed, bounced
+	Error     string `json:"error,omitempty"` // Причина failed
+
+	ComputeExitCode  *int32 `json:"compute_exit_code,omitempty"`  // Exit code compute фазы
+	ActionResultCode *int32 `json:"action_result_code,omitempty"` // Код результата action фазы
+	Aborted          bool   `json:"aborted,omitempty"`            // Транзакция прервана
+	Bounced          bool   `json:"bounced,omitempty"`            // Входящий возврат bounce
+	BounceOf         string `json:"bounce_of,omitempty"`          // У возврата: хеш исходной транзакции
+	BounceTxHash     string `json:"bounce_tx_hash,omitempty"`     // У вернувшегося перевода: хеш транзакции возврата
+
+	MsgHash string              `json:"msg_hash,omitempty"` // Хеш тела внешнего сообщения (hex)
+	Fees    *TransactionFeesDTO `json:"fees,omitempty"`     // Комиссии по фазам (только GET /api/v1/transactions/{hash})
+
+	Messages []*TransactionMessageDTO `json:"messages,omitempty"` // Все внутренние сообщения транзакции
+	Jetton   *JettonTransferDTO       `json:"jetton,omitempty"`   // Перевод жетонов (TEP-74), если распознан
+}
+
+type TransactionMessageDTO struct {
+	Direction    string             `json:"direction"`           // "in" или "out"
+	Counterparty string             `json:"counterparty"`        // Отправитель (in) или получатель (out)
+	Amount       string             `json:"amount"`              // Сумма в TON
+	Op           string             `json:"op,omitempty"`        // Опкод тела (hex)
+	Comment      string             `json:"comment,omitempty"`   // Текстовый комментарий
+	Encrypted    bool               `json:"encrypted,omitempty"` // Комментарий зашифрован (расшифрован, если кошелек не watch-only)
+	Bounce       bool               `json:"bounce,omitempty"`    // Флаг bounce исходящего сообщения
+	Bounced      bool               `json:"bounced,omitempty"`   // Входящий возврат bounce
+	Jetton       *JettonTransferDTO `json:"jetton,omitempty"`    // Перевод жетонов (TEP-74), если распознан
 }
 
 type GetTransactionsResponse struct {
@@ -78,20 +149,151 @@ type GetTransactionsResponse struct {
 	Address      string            `json:"address"`
 	Transactions []*TransactionDTO `json:"transactions"`
 	Total        int               `json:"total"`
+	NextCursor   string            `json:"next_cursor,omitempty"` // Курсор следующей страницы, пустой на последней
 }
 
 type SendCoinsRequest struct {
-	Recipient string `json:"recipient" binding:"required"` // Адрес получателя
-	Amount    string `json:"amount" binding:"required"`    // Сумма в TON (например "1.5")
-	Comment   string `json:"comment,omitempty"`            // Комментарий к транзакции
+	Recipient    string `json:"recipient" binding:"required"` // Адрес получателя
+	Amount       string `json:"amount,omitempty"`             // Сумма в TON (например "1.5"), не нужна при mode 128
+	Comment      string `json:"comment,omitempty"`            // Комментарий к транзакции
+	Mode         *uint8 `json:"mode,omitempty"`               // Режим отправки: 0, 64 или 128 + флаги 1, 2, 16, 32 (по умолчанию 3)
+	Bounce       *bool  `json:"bounce,omitempty"`             // Bounce флаг (по умолчанию из формата адреса)
+	AllowDestroy bool   `json:"allow_destroy,omitempty"`      // Разрешить флаг +32 (удаление кошелька при нулевом балансе)
+
+	EncryptComment bool   `json:"encrypt_comment,omitempty"` // Зашифровать комментарий публичным ключом получателя
+	Payload        string `json:"payload,omitempty"`         // Тело сообщения, base64 BOC (вызов контракта); нельзя вместе с comment
+	StateInit      string `json:"state_init,omitempty"`      // State-init для деплоя контракта получателя, base64 BOC
+}
+
+// Режимы отправки, допустимые в SendCoinsRequest.Mode
+const (
+	sendModeCarryInbound    = 64
+	sendModeCarryAllBalance = 128
+	sendFlagDestroyIfZero   = 32
+	sendModeKnownBits       = sendModeCarryInbound | sendModeCarryAllBalance | 1 | 2 | 16 | sendFlagDestroyIfZero
+)
+
+// Validate проверяет поля перевода, не требующие кошелька: режим отправки,
+// сумму, комментарий и payload. Сервис повторяет эти проверки при сборке
+// сообщения.
+func (r *SendCoinsRequest) Validate() error {
+	if r.Mode != nil {
+		mode := *r.Mode
+		if mode&^sendModeKnownBits != 0 {
+			return fmt.Errorf("unsupported send mode %d: allowed base modes 0, 64, 128 and flags 1, 2, 16, 32", mode)
+		}
+		if mode&sendModeCarryInbound != 0 && mode&sendModeCarryAllBalance != 0 {
+			return errors.New("send modes 64 and 128 cannot be combined")
+		}
+		if mode&sendFlagDestroyIfZero != 0 && !r.AllowDestroy {
+			return errors.New("send mode +32 destroys the wallet account when its balance reaches zero, set allow_destroy to confirm")
+		}
+	}
+
+	if r.Amount == "" && (r.Mode == nil || *r.Mode&sendModeCarryAllBalance == 0) {
+		return errors.New("amount is required unless mode includes 128")
+	}
+	if r.EncryptComment && r.Comment == "" {
+		return errors.New("encrypt_comment requires comment")
+	}
+	if r.Comment != "" && r.Payload != "" {
+		return errors.New("comment and payload cannot be combined")
+	}
+
+	return nil
 }
 
 type SendCoinsResponse struct {
-	Hash      string `json:"hash"`       // Хеш транзакции
-	Lt        uint64 `json:"lt"`         // Logical time
-	Address   string `json:"address"`    // Адрес отправителя
-	Amount    string `json:"amount"`     // Отправленная сумма
-	Fee       string `json:"fee"`        // Комиссия
-	Recipient string `json:"recipient"`  // Адрес получателя
+	ID        int64  `json:"id"`                // ID отправки для опроса статуса
+	Status    string `json:"status"`            // pending
+	MsgHash   string `json:"msg_hash"`          // Хеш тела внешнего сообщения (hex)
+	Address   string `json:"address"`           // Адрес отправителя
+	Amount    string `json:"amount,omitempty"`  // Запрошенная сумма
+	Recipient string `json:"recipient"`         // Адрес получателя
 	Comment   string `json:"comment,omitempty"` // Комментарий
+	Mode      uint8  `json:"mode"`              // Режим отправки
+	Bounce    bool   `json:"bounce"`            // Bounce флаг сообщения
+	ExpiresAt string `json:"expires_at"`        // Сообщение действительно до
+	Error     string `json:"error,omitempty"`   // Ошибка отправки: сообщение повторяется до expires_at, результат - по ID
+}
+
+type SendStatusResponse struct {
+	ID        int64  `json:"id"`                // ID отправки
+	Status    string `json:"status"`            // pending, confirmed, failed, bounced
+	MsgHash   string `json:"msg_hash"`          // Хеш тела внешнего сообщения (hex)
+	Hash      string `json:"hash,omitempty"`    // Хеш транзакции
+	Lt        uint64 `json:"lt,omitempty"`      // Logical time
+	Address   string `json:"address"`           // Адрес отправителя
+	Amount    string `json:"amount"`            // Отправленная сумма
+	Fee       string `json:"fee,omitempty"`     // Комиссия
+	Recipient string `json:"recipient"`         // Адрес получателя
+	Comment   string `json:"comment,omitempty"` // Комментарий
+	Error     string `json:"error,omitempty"`   // Причина failed или ошибка отправки
+	ExpiresAt string `json:"expires_at"`        // Сообщение действительно до
+
+	ComputeExitCode  *int32 `json:"compute_exit_code,omitempty"`  // Exit code compute фазы
+	ActionResultCode *int32 `json:"action_result_code,omitempty"` // Код результата action фазы
+	BounceTxHash     string `json:"bounce_tx_hash,omitempty"`     // Транзакция возврата, если перевод вернулся
+	CreatedAt        string `json:"created_at"`                   // Время отправки
+	UpdatedAt        string `json:"updated_at"`                   // Время последнего изменения статуса
+}
+
+type EstimateSendResponse struct {
+	Address          string `json:"address"`           // Адрес отправителя
+	Recipient        string `json:"recipient"`         // Адрес получателя
+	Mode             uint8  `json:"mode"`              // Режим отправки
+	Bounce           bool   `json:"bounce"`            // Bounce флаг сообщения
+	Deploy           bool   `json:"deploy"`            // Сообщение развернет контракт кошелька
+	Balance          string `json:"balance"`           // Текущий баланс
+	Amount           string `json:"amount"`            // Сумма исходящего сообщения
+	RecipientAmount  string `json:"recipient_amount"`  // Сумма, которую получит адресат
+	ImportFee        string `json:"import_fee"`        // Прием внешнего сообщения
+	StorageFee       string `json:"storage_fee"`       // Хранение
+	ComputeFee       string `json:"compute_fee"`       // Газ (оценка)
+	ForwardFee       string `json:"forward_fee"`       // Пересылка исходящего сообщения
+	TotalFee         string `json:"total_fee"`         // Итого комиссий
+	ResultingBalance string `json:"resulting_balance"` // Баланс после перевода
+	Sufficient       bool   `json:"sufficient"`        // Хватает ли средств
+	GasUsed          int64  `json:"gas_used"`          // Оценка расхода газа
+}
+
+type SendBatchItem struct {
+	Recipient string `json:"recipient" binding:"required"` // Адрес получателя
+	Amount    string `json:"amount" binding:"required"`    // Сумма в TON (например "1.5")
+	Comment   string `json:"comment,omitempty"`            // Комментарий к переводу
+}
+
+type SendBatchRequest struct {
+	Messages []*SendBatchItem `json:"messages" binding:"required,min=1,max=1000,dive"` // Переводы в порядке отправки
+}
+
+type BatchChunkDTO struct {
+	Index     int    `json:"index"`                // Номер части
+	Size      int    `json:"size"`                 // Число сообщений в части
+	Status    string `json:"status"`               // pending, failed или skipped
+	SendID    int64  `json:"send_id,omitempty"`    // ID отправки части для GET /api/v1/wallet/{id}/send/{send_id}
+	MsgHash   string `json:"msg_hash,omitempty"`   // Хеш тела внешнего сообщения (hex)
+	ExpiresAt string `json:"expires_at,omitempty"` // Сообщение действительно до
+	Error     string `json:"error,omitempty"`      // Причина ошибки; у pending - ошибка отправки, сообщение повторяется до expires_at
+}
+
+type BatchMessageDTO struct {
+	Index     int    `json:"index"`              // Номер перевода в запросе
+	Chunk     int    `json:"chunk"`              // Номер части, в которой отправлен перевод
+	Recipient string `json:"recipient"`          // Адрес получателя
+	Amount    string `json:"amount"`             // Сумма
+	Comment   string `json:"comment,omitempty"`  // Комментарий
+	Status    string `json:"status"`             // pending, failed или skipped
+	SendID    int64  `json:"send_id,omitempty"`  // ID отправки части
+	MsgHash   string `json:"msg_hash,omitempty"` // Хеш внешнего сообщения части
+	Error     string `json:"error,omitempty"`    // Причина ошибки
+}
+
+type SendBatchResponse struct {
+	Address  string             `json:"address"`  // Адрес отправителя
+	Total    int                `json:"total"`    // Всего переводов
+	Pending  int                `json:"pending"`  // Записано и отправлено, итог - по send_id части; не отправлять повторно
+	Failed   int                `json:"failed"`   // Не отправлено (failed и skipped)
+	Chunks   []*BatchChunkDTO   `json:"chunks"`   // Внешние сообщения
+	Messages []*BatchMessageDTO `json:"messages"` // Результат по каждому переводу
 }
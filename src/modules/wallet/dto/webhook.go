@@ -0,0 +1,55 @@
+package dto
+
+import "encoding/json"
+
+type WebhookSubscriptionRequest struct {
+	URL      string   `json:"url" binding:"required,url"`                                                                                                             // Адрес получателя (http или https)
+	Secret   string   `json:"secret,omitempty" binding:"omitempty,min=16"`                                                                                            // Ключ подписи; пусто - сгенерировать (при изменении - оставить прежний)
+	Events   []string `json:"events,omitempty" binding:"omitempty,dive,oneof=deposit.received send.confirmed send.failed send.bounced wallet.created wallet.deleted"` // Фильтр событий; пусто - все события
+	IsActive *bool    `json:"is_active,omitempty"`                                                                                                                    // По умолчанию true
+}
+
+type WebhookSubscriptionDTO struct {
+	ID        int64    `json:"id"`
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret,omitempty"` // Только в ответе на создание и изменение
+	Events    []string `json:"events"`           // Пусто - все события
+	IsActive  bool     `json:"is_active"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+type ListWebhookSubscriptionsResponse struct {
+	Subscriptions []*WebhookSubscriptionDTO `json:"subscriptions"`
+	Total         int                       `json:"total"`
+}
+
+type ListWebhookDeliveriesRequest struct {
+	Status string `form:"status" json:"status" binding:"omitempty,oneof=pending delivered failed"`
+	Limit  int    `form:"limit" json:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
+type WebhookDeliveryDTO struct {
+	ID             int64           `json:"id"`
+	EventID        string          `json:"event_id"` // Общий для всех доставок события
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"` // Тело запроса
+	Status         string          `json:"status"`  // pending, delivered, failed
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  string          `json:"next_attempt_at,omitempty"` // Для pending
+	ResponseStatus int             `json:"response_status,omitempty"` // HTTP статус последней попытки
+	Error          string          `json:"error,omitempty"`           // Ошибка последней попытки
+	DeliveredAt    string          `json:"delivered_at,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+}
+
+type ListWebhookDeliveriesResponse struct {
+	SubscriptionID int64                 `json:"subscription_id"`
+	Deliveries     []*WebhookDeliveryDTO `json:"deliveries"`
+	Total          int                   `json:"total"`
+}
+
+type ReplayWebhookDeliveriesResponse struct {
+	SubscriptionID int64 `json:"subscription_id"`
+	Replayed       int   `json:"replayed"` // Сколько доставок поставлено в очередь
+}
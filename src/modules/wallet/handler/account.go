@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"wallet_test/src/modules/wallet/dto"
+	"wallet_test/src/modules/wallet/service"
+)
+
+// GetAccount возвращает состояние любого аккаунта
+// @Summary Состояние аккаунта
+// @Description Читает аккаунт из блокчейна (в том числе не принадлежащий сервису): баланс, статус, последнюю транзакцию, хеши кода и данных. Для известных кошельков определяет версию, публичный ключ и seqno. send_bounce подсказывает bounce флаг перевода: неразвернутому контракту перевод нужно отправлять без bounce
+// @Tags accounts
+// @Produce json
+// @Param address path string true "Адрес в любом формате"
+// @Param network query string true "Сеть (mainnet, testnet)"
+// @Success 200 {object} dto.AccountResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/accounts/{address} [get]
+func (h *WalletHandler) GetAccount(c *gin.Context) {
+	var req dto.GetAccountRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	info, err := h.walletService.GetAccount(c.Request.Context(), c.Param("address"), req.Network)
+	if errors.Is(err, service.ErrInvalidAddress) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_address",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrNetworkNotConnected) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "network_not_available",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_get_account",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.AccountResponse{
+		Address:       info.Address,
+		NonBounceable: info.NonBounceable,
+		RawAddress:    info.RawAddress,
+		Network:       req.Network,
+		Bounceable:    info.Bounceable,
+		TestnetOnly:   info.TestnetOnly,
+		Status:        info.Status,
+		Deployed:      info.Status == "active",
+		SendBounce:    info.SendBounce,
+		Balance:       info.Balance,
+		LastTxLt:      info.LastTxLt,
+		LastTxHash:    info.LastTxHash,
+		CodeHash:      info.CodeHash,
+		DataHash:      info.DataHash,
+		FrozenHash:    info.FrozenHash,
+		Interface:     info.Interface,
+		WalletType:    info.WalletType,
+		PublicKey:     info.PublicKey,
+		Seqno:         info.Seqno,
+		WalletID:      info.WalletID,
+	})
+}
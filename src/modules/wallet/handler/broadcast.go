@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"wallet_test/src/modules/wallet/dto"
+	"wallet_test/src/modules/wallet/service"
+)
+
+// Broadcast отправляет внешнее сообщение, подписанное вне сервиса
+// @Summary Отправить подписанное сообщение
+// @Description Разбирает внешнее сообщение из base64 BOC и отправляет его в сеть, не дожидаясь транзакции. Возвращает нормализованный хеш сообщения. Если получатель - кошелек сервиса с известным публичным ключом, подпись проверяется этим ключом и записывается pending отправка, статус которой доступен через GET /api/v1/wallet/{id}/send/{send_id}; если ее не удалось отправить сразу, ответ 202 содержит error, а сообщение повторяется до expires_at
+// @Tags broadcast
+// @Accept json
+// @Produce json
+// @Param request body dto.BroadcastRequest true "Подписанное сообщение"
+// @Success 202 {object} dto.BroadcastResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/broadcast [post]
+func (h *WalletHandler) Broadcast(c *gin.Context) {
+	var req dto.BroadcastRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	result, err := h.walletService.BroadcastMessage(c.Request.Context(), req.Boc, req.Network)
+	if errors.Is(err, service.ErrInvalidExternalMessage) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_message",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrNetworkNotConnected) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "network_not_available",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil && (result == nil || result.Send == nil) {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "broadcast_failed",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	resp := dto.BroadcastResponse{
+		Hash:        result.Hash,
+		MsgHash:     result.MsgHash,
+		Destination: result.Destination.String(),
+		Network:     result.Network,
+	}
+	if send := result.Send; send != nil {
+		resp.WalletID = send.WalletID
+		resp.SendID = send.ID
+		resp.Status = send.Status
+		resp.ExpiresAt = send.ExpiresAt.Format("2006-01-02T15:04:05Z")
+	}
+	if err != nil {
+		// Отправка записана: ее повторит трекер до expires_at
+		resp.Error = err.Error()
+	}
+
+	c.JSON(http.StatusAccepted, resp)
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"wallet_test/src/modules/wallet/dto"
+	"wallet_test/src/modules/wallet/model"
+	"wallet_test/src/modules/wallet/service"
+)
+
+// ListHighloadQueries получает сообщения highload кошелька
+// @Summary Получить query_id highload кошелька
+// @Description Возвращает внешние сообщения HighloadV3 кошелька с выделенными query_id и их статусом
+// @Tags highload
+// @Accept json
+// @Produce json
+// @Param id path int true "ID кошелька"
+// @Param status query string false "Статус (pending, processed, expired)"
+// @Param limit query int false "Количество записей" default(100)
+// @Success 200 {object} dto.ListHighloadQueriesResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/wallet/{id}/highload/queries [get]
+func (h *WalletHandler) ListHighloadQueries(c *gin.Context) {
+	walletIDStr := c.Param("id")
+	walletID, err := strconv.ParseInt(walletIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_wallet_id",
+			Message: "ID кошелька должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.ListHighloadQueriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if req.Limit == 0 {
+		req.Limit = 100
+	}
+
+	_, err = h.walletService.GetWalletByID(c.Request.Context(), walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "wallet_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	queries, err := h.walletService.ListHighloadQueries(c.Request.Context(), walletID, req.Status, req.Limit)
+	if errors.Is(err, service.ErrNotHighloadWallet) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "not_highload_wallet",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_get_highload_queries",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	items := make([]*dto.HighloadQueryDTO, 0, len(queries))
+	for _, q := range queries {
+		items = append(items, highloadQueryDTO(q))
+	}
+
+	c.JSON(http.StatusOK, dto.ListHighloadQueriesResponse{
+		WalletID: walletID,
+		Queries:  items,
+		Total:    len(items),
+	})
+}
+
+// RetryHighloadQuery повторяет отправку сообщения highload кошелька
+// @Summary Повторить отправку highload сообщения
+// @Description Повторно отправляет сохраненное подписанное сообщение с тем же query_id, если контракт его еще не обработал. Обработанное или истекшее сообщение не отправляется
+// @Tags highload
+// @Accept json
+// @Produce json
+// @Param id path int true "ID кошелька"
+// @Param query path int true "ID записи"
+// @Success 200 {object} dto.HighloadQueryDTO
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/wallet/{id}/highload/queries/{query}/retry [post]
+func (h *WalletHandler) RetryHighloadQuery(c *gin.Context) {
+	walletIDStr := c.Param("id")
+	walletID, err := strconv.ParseInt(walletIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_wallet_id",
+			Message: "ID кошелька должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	queryID, err := strconv.ParseInt(c.Param("query"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_query_id",
+			Message: "ID записи должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	_, err = h.walletService.GetWalletByID(c.Request.Context(), walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "wallet_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	query, err := h.walletService.RetryHighloadQuery(c.Request.Context(), walletID, queryID)
+	if errors.Is(err, service.ErrNotHighloadWallet) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "not_highload_wallet",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrHighloadQueryNotFound) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "highload_query_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_retry_highload_query",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, highloadQueryDTO(query))
+}
+
+func highloadQueryDTO(q *model.HighloadQuery) *dto.HighloadQueryDTO {
+	return &dto.HighloadQueryDTO{
+		ID:           q.ID,
+		QueryID:      q.QueryID,
+		MsgCreatedAt: q.MsgCreatedAt,
+		ExpiresAt:    q.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+		MsgHash:      q.MsgHash,
+		Messages:     q.Messages,
+		Status:       q.Status,
+		TxHash:       q.TxHash,
+		Error:        q.Error,
+		CreatedAt:    q.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"wallet_test/src/modules/wallet/dto"
+	"wallet_test/src/modules/wallet/service"
+)
+
+// GetJettonBalances получает балансы жетонов кошелька
+// @Summary Получить балансы жетонов
+// @Description Возвращает балансы кошелька по указанным jetton master контрактам (TEP-74)
+// @Tags jetton
+// @Accept json
+// @Produce json
+// @Param id path int true "ID кошелька"
+// @Param master query []string true "Адреса jetton master контрактов" collectionFormat(multi)
+// @Success 200 {object} dto.GetJettonBalancesResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/wallet/{id}/jettons [get]
+func (h *WalletHandler) GetJettonBalances(c *gin.Context) {
+	walletIDStr := c.Param("id")
+	walletID, err := strconv.ParseInt(walletIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_wallet_id",
+			Message: "ID кошелька должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.GetJettonBalancesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	wallet, err := h.walletService.GetWalletByID(c.Request.Context(), walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "wallet_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	balances, err := h.walletService.GetJettonBalances(c.Request.Context(), walletID, req.Masters)
+	if errors.Is(err, service.ErrInvalidAddress) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_address",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_get_jetton_balances",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	jettons := make([]*dto.JettonBalanceDTO, 0, len(balances))
+	for _, b := range balances {
+		jettons = append(jettons, &dto.JettonBalanceDTO{
+			Master:       b.Jetton.Master,
+			JettonWallet: b.JettonWallet,
+			Symbol:       b.Jetton.Metadata.Symbol,
+			Name:         b.Jetton.Metadata.Name,
+			Decimals:     b.Jetton.Decimals,
+			Balance:      b.Balance,
+			BalanceUnits: b.BalanceUnits,
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.GetJettonBalancesResponse{
+		WalletID: wallet.ID,
+		Address:  wallet.Address,
+		Jettons:  jettons,
+		Total:    len(jettons),
+	})
+}
+
+// GetJettonInfo получает метаданные жетона
+// @Summary Получить информацию о жетоне
+// @Description Возвращает данные jetton master контракта и метаданные (onchain или offchain)
+// @Tags jetton
+// @Accept json
+// @Produce json
+// @Param master path string true "Адрес jetton master контракта"
+// @Param network query string true "Сеть (mainnet или testnet)"
+// @Success 200 {object} dto.JettonInfoResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/jettons/{master} [get]
+func (h *WalletHandler) GetJettonInfo(c *gin.Context) {
+	var req dto.GetJettonInfoRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	info, err := h.walletService.GetJettonInfo(c.Request.Context(), c.Param("master"), req.Network)
+	if errors.Is(err, service.ErrInvalidAddress) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_address",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrNetworkNotConnected) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "network_not_available",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_get_jetton_info",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.JettonInfoResponse{
+		Master:      info.Master,
+		TotalSupply: info.TotalSupply,
+		Mintable:    info.Mintable,
+		Admin:       info.Admin,
+		Decimals:    info.Decimals,
+		Metadata: &dto.JettonMetadataDTO{
+			URI:         info.Metadata.URI,
+			Name:        info.Metadata.Name,
+			Description: info.Metadata.Description,
+			Symbol:      info.Metadata.Symbol,
+			Image:       info.Metadata.Image,
+		},
+	})
+}
+
+// SendJetton отправляет жетоны на другой адрес
+// @Summary Отправить жетоны
+// @Description Отправляет transfer (TEP-74) через jetton-кошелек отправителя, не дожидаясь транзакции. Отправка получает seqno в общей очереди кошелька; итоговый статус доступен через GET /api/v1/wallet/{id}/send/{send_id}. Если liteserver не принял сообщение, ответ 202 содержит error, а сообщение повторяется до expires_at
+// @Tags jetton
+// @Accept json
+// @Produce json
+// @Param id path int true "ID кошелька"
+// @Param request body dto.SendJettonRequest true "Данные перевода"
+// @Success 202 {object} dto.SendJettonResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/wallet/{id}/jettons/send [post]
+func (h *WalletHandler) SendJetton(c *gin.Context) {
+	walletIDStr := c.Param("id")
+	walletID, err := strconv.ParseInt(walletIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_wallet_id",
+			Message: "ID кошелька должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.SendJettonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	// Проверяем существование кошелька
+	_, err = h.walletService.GetWalletByID(c.Request.Context(), walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "wallet_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	result, err := h.walletService.SendJetton(c.Request.Context(), walletID, &service.JettonTransfer{
+		Master:              req.Master,
+		Recipient:           req.Recipient,
+		Amount:              req.Amount,
+		ForwardAmount:       req.ForwardAmount,
+		ForwardComment:      req.ForwardComment,
+		ResponseDestination: req.ResponseDestination,
+	})
+	if errors.Is(err, service.ErrWatchOnlyWallet) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "watch_only_wallet",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidAddress) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_address",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil && result == nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_send_jetton",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	resp := dto.SendJettonResponse{
+		ID:           result.Send.ID,
+		Status:       result.Send.Status,
+		MsgHash:      result.Send.MsgHash,
+		Address:      result.Send.FromAddress,
+		Master:       result.Master,
+		JettonWallet: result.JettonWallet,
+		Amount:       result.Amount,
+		Recipient:    result.Recipient,
+		Comment:      result.Comment,
+		ExpiresAt:    result.Send.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+	}
+	// Отправка записана, но liteserver вернул ошибку: повторять перевод нельзя
+	if err != nil {
+		resp.Error = err.Error()
+	}
+
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// jettonTransferDTO преобразует распознанный перевод жетонов для ответа
+func jettonTransferDTO(info *service.JettonTransferInfo) *dto.JettonTransferDTO {
+	if info == nil {
+		return nil
+	}
+
+	return &dto.JettonTransferDTO{
+		Direction:    info.Direction,
+		QueryID:      info.QueryID,
+		Amount:       info.Amount,
+		Counterparty: info.Counterparty,
+		JettonWallet: info.JettonWallet,
+		Comment:      info.Comment,
+	}
+}
@@ -0,0 +1,311 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"wallet_test/src/modules/wallet/dto"
+	"wallet_test/src/modules/wallet/service"
+)
+
+// defaultNFTScan - сколько последних транзакций просматривать в поиске NFT
+const defaultNFTScan = 100
+
+// ListNFTs получает NFT кошелька
+// @Summary Получить NFT кошелька
+// @Description Ищет NFT (TEP-62) в последних scan транзакциях кошелька и в переданных адресах, проверяя текущего владельца. NFT из более старой истории и полученные без уведомления находятся только через item; history_complete показывает, просмотрена ли вся история. Кандидаты, которые не удалось проверить, возвращаются в errors
+// @Tags nft
+// @Accept json
+// @Produce json
+// @Param id path int true "ID кошелька"
+// @Param scan query int false "Сколько последних транзакций просмотреть" default(100)
+// @Param item query []string false "Адреса NFT item для явной проверки" collectionFormat(multi)
+// @Success 200 {object} dto.ListNFTsResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/wallet/{id}/nfts [get]
+func (h *WalletHandler) ListNFTs(c *gin.Context) {
+	walletIDStr := c.Param("id")
+	walletID, err := strconv.ParseInt(walletIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_wallet_id",
+			Message: "ID кошелька должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.ListNFTsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if req.Scan == 0 {
+		req.Scan = defaultNFTScan
+	}
+
+	wallet, err := h.walletService.GetWalletByID(c.Request.Context(), walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "wallet_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	nfts, err := h.walletService.ListNFTs(c.Request.Context(), walletID, req.Scan, req.Items)
+	if errors.Is(err, service.ErrInvalidAddress) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_address",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_get_nfts",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	items := make([]*dto.NFTItemDTO, 0, len(nfts.Items))
+	for _, item := range nfts.Items {
+		items = append(items, nftItemDTO(item))
+	}
+
+	var itemErrors []*dto.NFTItemErrorDTO
+	for _, itemErr := range nfts.Errors {
+		itemErrors = append(itemErrors, &dto.NFTItemErrorDTO{Item: itemErr.Item, Error: itemErr.Error})
+	}
+
+	c.JSON(http.StatusOK, dto.ListNFTsResponse{
+		WalletID:        wallet.ID,
+		Address:         wallet.Address,
+		Items:           items,
+		Total:           len(items),
+		Scanned:         nfts.Scanned,
+		HistoryComplete: nfts.HistoryComplete,
+		Errors:          itemErrors,
+	})
+}
+
+// GetNFTItem получает NFT item кошелька
+// @Summary Получить NFT
+// @Description Возвращает данные NFT item и коллекции с метаданными (TEP-64), если item принадлежит кошельку
+// @Tags nft
+// @Accept json
+// @Produce json
+// @Param id path int true "ID кошелька"
+// @Param item path string true "Адрес NFT item"
+// @Success 200 {object} dto.NFTItemDTO
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/wallet/{id}/nfts/{item} [get]
+func (h *WalletHandler) GetNFTItem(c *gin.Context) {
+	walletIDStr := c.Param("id")
+	walletID, err := strconv.ParseInt(walletIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_wallet_id",
+			Message: "ID кошелька должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	_, err = h.walletService.GetWalletByID(c.Request.Context(), walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "wallet_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	item, err := h.walletService.GetNFTItem(c.Request.Context(), walletID, c.Param("item"))
+	if errors.Is(err, service.ErrInvalidAddress) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_address",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrNFTNotOwned) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "nft_not_owned",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_get_nft",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, nftItemDTO(item))
+}
+
+// TransferNFT передает NFT другому владельцу
+// @Summary Передать NFT
+// @Description Отправляет transfer (TEP-62) на NFT item, которым владеет кошелек, не дожидаясь транзакции. Отправка получает seqno в общей очереди кошелька; итоговый статус доступен через GET /api/v1/wallet/{id}/send/{send_id}. Если liteserver не принял сообщение, ответ 202 содержит error, а сообщение повторяется до expires_at
+// @Tags nft
+// @Accept json
+// @Produce json
+// @Param id path int true "ID кошелька"
+// @Param item path string true "Адрес NFT item"
+// @Param request body dto.TransferNFTRequest true "Данные передачи"
+// @Success 202 {object} dto.TransferNFTResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/wallet/{id}/nfts/{item}/transfer [post]
+func (h *WalletHandler) TransferNFT(c *gin.Context) {
+	walletIDStr := c.Param("id")
+	walletID, err := strconv.ParseInt(walletIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_wallet_id",
+			Message: "ID кошелька должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.TransferNFTRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	// Проверяем существование кошелька
+	_, err = h.walletService.GetWalletByID(c.Request.Context(), walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "wallet_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	result, err := h.walletService.TransferNFT(c.Request.Context(), walletID, &service.NFTTransfer{
+		Item:                c.Param("item"),
+		NewOwner:            req.NewOwner,
+		QueryID:             req.QueryID,
+		ForwardAmount:       req.ForwardAmount,
+		ForwardComment:      req.ForwardComment,
+		ResponseDestination: req.ResponseDestination,
+	})
+	if errors.Is(err, service.ErrWatchOnlyWallet) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "watch_only_wallet",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidAddress) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_address",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrNFTNotOwned) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "nft_not_owned",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil && result == nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_transfer_nft",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	resp := dto.TransferNFTResponse{
+		ID:        result.Send.ID,
+		Status:    result.Send.Status,
+		MsgHash:   result.Send.MsgHash,
+		Address:   result.Send.FromAddress,
+		Item:      result.Item,
+		NewOwner:  result.NewOwner,
+		QueryID:   result.QueryID,
+		Amount:    result.Amount,
+		Comment:   result.Comment,
+		ExpiresAt: result.Send.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+	}
+	// Отправка записана, но liteserver вернул ошибку: повторять передачу нельзя
+	if err != nil {
+		resp.Error = err.Error()
+	}
+
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// nftItemDTO преобразует NFT item для ответа
+func nftItemDTO(item *service.NFTItemInfo) *dto.NFTItemDTO {
+	result := &dto.NFTItemDTO{
+		Address:       item.Address,
+		Index:         item.Index,
+		Owner:         item.Owner,
+		Metadata:      nftMetadataDTO(item.Metadata),
+		MetadataError: item.MetadataError,
+	}
+
+	if item.Collection != nil {
+		result.Collection = &dto.NFTCollectionDTO{
+			Address:       item.Collection.Address,
+			Owner:         item.Collection.Owner,
+			NextItemIndex: item.Collection.NextItemIndex,
+			Metadata:      nftMetadataDTO(item.Collection.Metadata),
+			MetadataError: item.Collection.MetadataError,
+		}
+	}
+
+	return result
+}
+
+func nftMetadataDTO(meta *service.ContentMetadata) *dto.NFTMetadataDTO {
+	if meta == nil {
+		return nil
+	}
+
+	return &dto.NFTMetadataDTO{
+		URI:         meta.URI,
+		Name:        meta.Name,
+		Description: meta.Description,
+		Image:       meta.Image,
+	}
+}
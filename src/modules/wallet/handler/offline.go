@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"wallet_test/src/modules/wallet/dto"
+	"wallet_test/src/modules/wallet/service"
+)
+
+// RegisterColdWallet регистрирует холодный кошелек по публичному ключу
+// @Summary Добавить холодный кошелек
+// @Description Регистрирует кошелек по публичному ключу из signer keygen. Seed фраза остается на офлайн машине: переводы готовятся через /offline/transfers, подписываются утилитой signer и отправляются через /offline/broadcast
+// @Tags offline
+// @Accept json
+// @Produce json
+// @Param request body dto.RegisterColdWalletRequest true "Публичный ключ, версия и сеть кошелька"
+// @Success 201 {object} dto.CreateWalletResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/wallet/cold [post]
+func (h *WalletHandler) RegisterColdWallet(c *gin.Context) {
+	var req dto.RegisterColdWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	wallet, err := h.walletService.RegisterColdWallet(c.Request.Context(), req.UserID, req.PublicKey, req.Address, req.WalletType, req.Network)
+	if errors.Is(err, service.ErrInvalidPublicKey) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_public_key",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidAddress) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_address",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrNetworkNotConnected) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "network_not_available",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrWalletExists) {
+		c.JSON(http.StatusConflict, dto.ErrorResponse{
+			Error:   "wallet_already_exists",
+			Message: err.Error(),
+			Code:    http.StatusConflict,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "wallet_register_failed",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.CreateWalletResponse{
+		ID:          wallet.ID,
+		Address:     wallet.Address,
+		PublicKey:   wallet.PublicKey,
+		WalletType:  wallet.WalletType,
+		Network:     wallet.Network,
+		IsWatchOnly: wallet.IsWatchOnly,
+		IsCold:      wallet.IsCold,
+		CreatedAt:   wallet.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	})
+}
+
+// PrepareOfflineTransfer готовит неподписанный перевод холодного кошелька
+// @Summary Подготовить перевод для офлайн подписи
+// @Description Возвращает перевод с текущим seqno, valid_until и внутренними сообщениями. Его нужно подписать на офлайн машине командой signer sign и отправить через /offline/broadcast до истечения valid_until. Тело каждого сообщения описано полем comment или payload, state-init - полем state_init; signer отклоняет сообщения, несущие что-либо сверх описания
+// @Tags offline
+// @Accept json
+// @Produce json
+// @Param id path int true "ID кошелька"
+// @Param request body dto.OfflineTransferRequest true "Переводы"
+// @Success 200 {object} dto.OfflineTransferDTO
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/wallet/{id}/offline/transfers [post]
+func (h *WalletHandler) PrepareOfflineTransfer(c *gin.Context) {
+	walletIDStr := c.Param("id")
+	walletID, err := strconv.ParseInt(walletIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_wallet_id",
+			Message: "ID кошелька должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.OfflineTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	transfers := make([]*service.TONTransfer, 0, len(req.Messages))
+	for i, m := range req.Messages {
+		if err := m.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Error:   "invalid_transfer",
+				Message: fmt.Sprintf("message %d: %v", i, err),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+
+		transfers = append(transfers, &service.TONTransfer{
+			Recipient:    m.Recipient,
+			Amount:       m.Amount,
+			Comment:      m.Comment,
+			Mode:         m.Mode,
+			Bounce:       m.Bounce,
+			AllowDestroy: m.AllowDestroy,
+
+			EncryptComment: m.EncryptComment,
+			Payload:        m.Payload,
+			StateInit:      m.StateInit,
+		})
+	}
+
+	// Проверяем существование кошелька
+	_, err = h.walletService.GetWalletByID(c.Request.Context(), walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "wallet_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	transfer, err := h.walletService.PrepareOfflineTransfer(c.Request.Context(), walletID, transfers, time.Duration(req.TTL)*time.Second)
+	if errors.Is(err, service.ErrNotColdWallet) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "not_cold_wallet",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrOfflineNotSupported) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "offline_not_supported",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidTransfer) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_transfer",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_prepare_transfer",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	resp := dto.OfflineTransferDTO{
+		Version:    transfer.Version,
+		WalletID:   transfer.WalletID,
+		Address:    transfer.Address,
+		PublicKey:  transfer.PublicKey,
+		WalletType: transfer.WalletType,
+		Network:    transfer.Network,
+		Subwallet:  transfer.Subwallet,
+		Seqno:      transfer.Seqno,
+		ValidUntil: transfer.ValidUntil,
+		Deploy:     transfer.Deploy,
+		Messages:   make([]*dto.OfflineMessageDTO, 0, len(transfer.Messages)),
+		Payload:    transfer.Payload,
+		ExpiresAt:  time.Unix(transfer.ValidUntil, 0).UTC().Format("2006-01-02T15:04:05Z"),
+	}
+	for _, m := range transfer.Messages {
+		resp.Messages = append(resp.Messages, &dto.OfflineMessageDTO{
+			Mode:      m.Mode,
+			Recipient: m.Recipient,
+			Amount:    m.Amount,
+			Bounce:    m.Bounce,
+			Comment:   m.Comment,
+			Payload:   m.Payload,
+			StateInit: m.StateInit,
+			Message:   m.Message,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// BroadcastOfflineTransfer отправляет перевод, подписанный утилитой signer
+// @Summary Отправить подписанный офлайн перевод
+// @Description Принимает результат signer sign (достаточно поля boc), проверяет, что сообщение адресовано кошельку и подписано его ключом, и отправляет его в сеть. Статус отправки доступен через GET /api/v1/wallet/{id}/send/{send_id}; если сообщение не удалось отправить сразу, ответ 202 содержит error, а сообщение повторяется до expires_at
+// @Tags offline
+// @Accept json
+// @Produce json
+// @Param id path int true "ID кошелька"
+// @Param request body dto.OfflineBroadcastRequest true "Подписанный перевод"
+// @Success 202 {object} dto.BroadcastResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/wallet/{id}/offline/broadcast [post]
+func (h *WalletHandler) BroadcastOfflineTransfer(c *gin.Context) {
+	walletIDStr := c.Param("id")
+	walletID, err := strconv.ParseInt(walletIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_wallet_id",
+			Message: "ID кошелька должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.OfflineBroadcastRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	// Проверяем существование кошелька
+	_, err = h.walletService.GetWalletByID(c.Request.Context(), walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "wallet_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	result, err := h.walletService.BroadcastOfflineTransfer(c.Request.Context(), walletID, req.Boc)
+	if errors.Is(err, service.ErrNotColdWallet) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "not_cold_wallet",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidExternalMessage) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_message",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil && (result == nil || result.Send == nil) {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "broadcast_failed",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	resp := dto.BroadcastResponse{
+		Hash:        result.Hash,
+		MsgHash:     result.MsgHash,
+		Destination: result.Destination.String(),
+		Network:     result.Network,
+	}
+	if send := result.Send; send != nil {
+		resp.WalletID = send.WalletID
+		resp.SendID = send.ID
+		resp.Status = send.Status
+		resp.ExpiresAt = send.ExpiresAt.Format("2006-01-02T15:04:05Z")
+	}
+	if err != nil {
+		// Отправка записана: ее повторит трекер до expires_at
+		resp.Error = err.Error()
+	}
+
+	c.JSON(http.StatusAccepted, resp)
+}
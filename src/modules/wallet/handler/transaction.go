@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"wallet_test/src/modules/wallet/dto"
+	"wallet_test/src/modules/wallet/model"
+	"wallet_test/src/modules/wallet/service"
+)
+
+// LookupTransaction ищет транзакцию по хешу
+// @Summary Найти транзакцию по хешу
+// @Description Ищет транзакцию по хешу транзакции или хешу внешнего сообщения (хеш тела или нормализованный, TEP-467) в hex или base64. Сначала проверяется история кошельков сервиса, затем блокчейн по address и network (с lt транзакция читается напрямую, без него просматриваются последние 100 транзакций аккаунта). Разбивка комиссий читается из блокчейна и отсутствует у отправок без транзакции
+// @Tags transactions
+// @Produce json
+// @Param hash path string true "Хеш транзакции или внешнего сообщения"
+// @Param address query string false "Аккаунт транзакции"
+// @Param lt query int false "lt транзакции"
+// @Param network query string false "Сеть (mainnet, testnet), обязательна вместе с address"
+// @Success 200 {object} dto.LookupTransactionResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/transactions/{hash} [get]
+func (h *WalletHandler) LookupTransaction(c *gin.Context) {
+	var req dto.LookupTransactionRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if req.Address != "" && req.Network == "" {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "network is required with address",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	lookup, err := h.walletService.LookupTransaction(c.Request.Context(), &service.TransactionQuery{
+		Hash:    c.Param("hash"),
+		Address: req.Address,
+		Lt:      req.Lt,
+		Network: req.Network,
+	})
+	if errors.Is(err, service.ErrInvalidTxHash) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_hash",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidAddress) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_address",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrNetworkNotConnected) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "network_not_available",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrTransactionNotFound) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "transaction_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_get_transaction",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	tx := transactionDTO(lookup.Transaction)
+	if fees := lookup.Fees; fees != nil {
+		tx.Fees = &dto.TransactionFeesDTO{
+			Total:   fees.Total,
+			Import:  fees.Import,
+			Storage: fees.Storage,
+			Compute: fees.Compute,
+			Action:  fees.Action,
+			Forward: fees.Forward,
+			GasUsed: fees.GasUsed,
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.LookupTransactionResponse{
+		Account:     lookup.Account,
+		Network:     lookup.Network,
+		WalletID:    lookup.Transaction.WalletID,
+		Source:      lookup.Source,
+		Transaction: tx,
+	})
+}
+
+func transactionDTO(tx *model.Transaction) *dto.TransactionDTO {
+	var timestamp int64
+	if !tx.TxTime.IsZero() {
+		timestamp = tx.TxTime.Unix()
+	}
+
+	return &dto.TransactionDTO{
+		Hash:      tx.TxHash,
+		Lt:        tx.Lt,
+		Timestamp: timestamp,
+		Type:      tx.Direction,
+		Amount:    tx.Amount,
+		NetAmount: tx.NetAmount,
+		Fee:       tx.Fee,
+		From:      tx.FromAddress,
+		To:        tx.ToAddress,
+		Comment:   tx.Comment,
+		Success:   tx.Status == service.TxStatusConfirmed,
+		Status:    tx.Status,
+		Error:     tx.Error,
+
+		ComputeExitCode:  tx.ComputeExitCode,
+		ActionResultCode: tx.ActionResultCode,
+		Aborted:          tx.Aborted,
+		Bounced:          tx.Bounced,
+		BounceOf:         tx.BounceOf,
+		BounceTxHash:     tx.BounceTxHash,
+
+		MsgHash: tx.MsgHash,
+
+		Messages: transactionMessageDTOs(tx.Messages),
+		Jetton:   jettonTransferDTO(tx.Jetton),
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -42,7 +43,23 @@ func (h *WalletHandler) CreateWallet(c *gin.Context) {
 		return
 	}
 
-	wallet, err := h.walletService.CreateWallet(c.Request.Context(), req.UserID, req.WalletType, req.Network)
+	wallet, err := h.walletService.CreateWallet(c.Request.Context(), req.UserID, req.WalletType, req.Network, req.SubwalletID)
+	if errors.Is(err, service.ErrSubwalletNotSupported) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "subwallet_not_supported",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrNetworkNotConnected) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "network_not_available",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Error:   "wallet_creation_failed",
@@ -53,11 +70,173 @@ func (h *WalletHandler) CreateWallet(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, dto.CreateWalletResponse{
-		ID:         wallet.ID,
-		Address:    wallet.Address,
-		WalletType: wallet.WalletType,
-		Network:    wallet.Network,
-		CreatedAt:  wallet.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		ID:          wallet.ID,
+		Address:     wallet.Address,
+		PublicKey:   wallet.PublicKey,
+		WalletType:  wallet.WalletType,
+		Network:     wallet.Network,
+		SubwalletID: wallet.SubwalletID,
+		IsWatchOnly: wallet.IsWatchOnly,
+		IsCold:      wallet.IsCold,
+		CreatedAt:   wallet.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	})
+}
+
+// ImportWallet импортирует существующий кошелек по мнемонике
+// @Summary Импортировать кошелек
+// @Description Добавляет существующий TON кошелек по 24-словной мнемонике
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Param request body dto.ImportWalletRequest true "Мнемоника и параметры кошелька"
+// @Success 201 {object} dto.CreateWalletResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/wallet/import [post]
+func (h *WalletHandler) ImportWallet(c *gin.Context) {
+	var req dto.ImportWalletRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	wallet, err := h.walletService.ImportWallet(c.Request.Context(), req.UserID, req.Mnemonic, req.MnemonicPassword, req.WalletType, req.Network, req.SubwalletID)
+	if errors.Is(err, service.ErrInvalidMnemonic) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_mnemonic",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrSubwalletNotSupported) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "subwallet_not_supported",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrNetworkNotConnected) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "network_not_available",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrWalletExists) {
+		c.JSON(http.StatusConflict, dto.ErrorResponse{
+			Error:   "wallet_already_exists",
+			Message: err.Error(),
+			Code:    http.StatusConflict,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "wallet_import_failed",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.CreateWalletResponse{
+		ID:          wallet.ID,
+		Address:     wallet.Address,
+		PublicKey:   wallet.PublicKey,
+		WalletType:  wallet.WalletType,
+		Network:     wallet.Network,
+		SubwalletID: wallet.SubwalletID,
+		IsWatchOnly: wallet.IsWatchOnly,
+		IsCold:      wallet.IsCold,
+		CreatedAt:   wallet.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	})
+}
+
+// WatchWallet регистрирует watch-only кошелек по адресу
+// @Summary Добавить watch-only кошелек
+// @Description Регистрирует кошелек по адресу без seed фразы: доступны баланс, история и информация, отправка запрещена. Тип кошелька определяется по коду контракта, для неразвернутого контракта wallet_type обязателен
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Param request body dto.WatchWalletRequest true "Адрес и сеть кошелька"
+// @Success 201 {object} dto.CreateWalletResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/wallet/watch [post]
+func (h *WalletHandler) WatchWallet(c *gin.Context) {
+	var req dto.WatchWalletRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	wallet, err := h.walletService.WatchWallet(c.Request.Context(), req.UserID, req.Address, req.WalletType, req.Network)
+	if errors.Is(err, service.ErrInvalidAddress) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_address",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrNetworkNotConnected) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "network_not_available",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrUnknownWallet) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "unknown_wallet_type",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrWalletExists) {
+		c.JSON(http.StatusConflict, dto.ErrorResponse{
+			Error:   "wallet_already_exists",
+			Message: err.Error(),
+			Code:    http.StatusConflict,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "wallet_watch_failed",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.CreateWalletResponse{
+		ID:          wallet.ID,
+		Address:     wallet.Address,
+		PublicKey:   wallet.PublicKey,
+		WalletType:  wallet.WalletType,
+		Network:     wallet.Network,
+		SubwalletID: wallet.SubwalletID,
+		IsWatchOnly: wallet.IsWatchOnly,
+		IsCold:      wallet.IsCold,
+		CreatedAt:   wallet.CreatedAt.Format("2006-01-02T15:04:05Z"),
 	})
 }
 
@@ -106,14 +285,22 @@ func (h *WalletHandler) GetWalletInfo(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, dto.GetWalletInfoResponse{
-		ID:         wallet.ID,
-		Address:    info.Address,
-		Balance:    info.Balance,
-		WalletType: info.WalletType,
-		Network:    wallet.Network,
-		Seqno:      info.Seqno,
-		IsActive:   wallet.IsActive,
-		CreatedAt:  wallet.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		ID:          wallet.ID,
+		Address:     info.Address,
+		PublicKey:   wallet.PublicKey,
+		Balance:     info.Balance,
+		WalletType:  info.WalletType,
+		Network:     wallet.Network,
+		Seqno:       info.Seqno,
+		Status:      info.Status,
+		LastTxLt:    info.LastTxLt,
+		LastTxHash:  info.LastTxHash,
+		CodeHash:    info.CodeHash,
+		SubwalletID: wallet.SubwalletID,
+		IsActive:    wallet.IsActive,
+		IsWatchOnly: wallet.IsWatchOnly,
+		IsCold:      wallet.IsCold,
+		CreatedAt:   wallet.CreatedAt.Format("2006-01-02T15:04:05Z"),
 	})
 }
 
@@ -203,12 +390,15 @@ func (h *WalletHandler) ListUserWallets(c *gin.Context) {
 	summaries := make([]dto.WalletSummary, 0, len(wallets))
 	for _, w := range wallets {
 		summaries = append(summaries, dto.WalletSummary{
-			ID:         w.ID,
-			Address:    w.Address,
-			WalletType: w.WalletType,
-			Network:    w.Network,
-			IsActive:   w.IsActive,
-			CreatedAt:  w.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			ID:          w.ID,
+			Address:     w.Address,
+			PublicKey:   w.PublicKey,
+			WalletType:  w.WalletType,
+			Network:     w.Network,
+			IsActive:    w.IsActive,
+			IsWatchOnly: w.IsWatchOnly,
+			IsCold:      w.IsCold,
+			CreatedAt:   w.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		})
 	}
 
@@ -259,12 +449,17 @@ func (h *WalletHandler) DeleteWallet(c *gin.Context) {
 
 // GetTransactions получает историю транзакций кошелька
 // @Summary Получить историю транзакций
-// @Description Возвращает список транзакций кошелька из блокчейна TON
+// @Description Возвращает транзакции кошелька, сохраненные фоновым индексатором, и еще не подтвержденные отправки
 // @Tags wallet
 // @Accept json
 // @Produce json
 // @Param id path int true "ID кошелька"
 // @Param limit query int false "Количество транзакций (по умолчанию 10, макс 100)" default(10)
+// @Param from_date query string false "Не раньше (RFC3339)"
+// @Param to_date query string false "Раньше (RFC3339)"
+// @Param direction query string false "Направление" Enums(in, out)
+// @Param status query string false "Статус" Enums(pending, confirmed, failed, bounced)
+// @Param cursor query string false "next_cursor предыдущей страницы"
 // @Success 200 {object} dto.GetTransactionsResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
@@ -308,7 +503,22 @@ func (h *WalletHandler) GetTransactions(c *gin.Context) {
 		return
 	}
 
-	transactions, err := h.walletService.GetTransactions(c.Request.Context(), walletID, limit)
+	transactions, nextCursor, err := h.walletService.GetTransactions(c.Request.Context(), walletID, &service.TransactionFilter{
+		From:      req.FromDate,
+		To:        req.ToDate,
+		Direction: req.Direction,
+		Status:    req.Status,
+		Cursor:    req.Cursor,
+		Limit:     limit,
+	})
+	if errors.Is(err, service.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_cursor",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Error:   "failed_to_get_transactions",
@@ -321,18 +531,7 @@ func (h *WalletHandler) GetTransactions(c *gin.Context) {
 	// Преобразуем в DTO
 	txDTOs := make([]*dto.TransactionDTO, 0, len(transactions))
 	for _, tx := range transactions {
-		txDTOs = append(txDTOs, &dto.TransactionDTO{
-			Hash:      tx.Hash,
-			Lt:        tx.Lt,
-			Timestamp: tx.Timestamp,
-			Type:      tx.Type,
-			Amount:    tx.Amount,
-			Fee:       tx.Fee,
-			From:      tx.From,
-			To:        tx.To,
-			Comment:   tx.Comment,
-			Success:   tx.Success,
-		})
+		txDTOs = append(txDTOs, transactionDTO(tx))
 	}
 
 	c.JSON(http.StatusOK, dto.GetTransactionsResponse{
@@ -340,18 +539,37 @@ func (h *WalletHandler) GetTransactions(c *gin.Context) {
 		Address:      wallet.Address,
 		Transactions: txDTOs,
 		Total:        len(txDTOs),
+		NextCursor:   nextCursor,
 	})
 }
 
+func transactionMessageDTOs(messages []*service.TxMessageInfo) []*dto.TransactionMessageDTO {
+	result := make([]*dto.TransactionMessageDTO, 0, len(messages))
+	for _, msg := range messages {
+		result = append(result, &dto.TransactionMessageDTO{
+			Direction:    msg.Direction,
+			Counterparty: msg.Counterparty,
+			Amount:       msg.Amount,
+			Op:           msg.Op,
+			Comment:      msg.Comment,
+			Encrypted:    msg.Encrypted,
+			Bounce:       msg.Bounce,
+			Bounced:      msg.Bounced,
+			Jetton:       jettonTransferDTO(msg.Jetton),
+		})
+	}
+	return result
+}
+
 // SendCoins отправляет TON монеты на другой кошелек
 // @Summary Отправить TON монеты
-// @Description Отправляет указанную сумму TON с кошелька на другой адрес
+// @Description Подписывает и отправляет перевод TON, не дожидаясь транзакции. Возвращает ID отправки со статусом pending; итоговый статус доступен через GET /api/v1/wallet/{id}/send/{send_id}. Отправки одного кошелька получают seqno по очереди. Если liteserver не принял сообщение, ответ 202 содержит error, а сообщение повторяется до expires_at - не отправляйте перевод заново. mode 128 отправляет весь баланс, флаг +32 требует allow_destroy. encrypt_comment шифрует комментарий публичным ключом получателя (get_public_key его контракта). payload и state_init задают тело сообщения и state-init в base64 BOC
 // @Tags wallet
 // @Accept json
 // @Produce json
 // @Param id path int true "ID кошелька"
 // @Param request body dto.SendCoinsRequest true "Данные для отправки"
-// @Success 200 {object} dto.SendCoinsResponse
+// @Success 202 {object} dto.SendCoinsResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
@@ -378,6 +596,15 @@ func (h *WalletHandler) SendCoins(c *gin.Context) {
 		return
 	}
 
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_transfer",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
 	// Проверяем существование кошелька
 	_, err = h.walletService.GetWalletByID(c.Request.Context(), walletID)
 	if err != nil {
@@ -390,8 +617,35 @@ func (h *WalletHandler) SendCoins(c *gin.Context) {
 	}
 
 	// Отправляем транзакцию
-	result, err := h.walletService.SendCoins(c.Request.Context(), walletID, req.Recipient, req.Amount, req.Comment)
-	if err != nil {
+	tx, prepared, err := h.walletService.SendCoins(c.Request.Context(), walletID, &service.TONTransfer{
+		Recipient:    req.Recipient,
+		Amount:       req.Amount,
+		Comment:      req.Comment,
+		Mode:         req.Mode,
+		Bounce:       req.Bounce,
+		AllowDestroy: req.AllowDestroy,
+
+		EncryptComment: req.EncryptComment,
+		Payload:        req.Payload,
+		StateInit:      req.StateInit,
+	})
+	if errors.Is(err, service.ErrWatchOnlyWallet) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "watch_only_wallet",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidTransfer) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_transfer",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil && tx == nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Error:   "failed_to_send_coins",
 			Message: err.Error(),
@@ -400,13 +654,334 @@ func (h *WalletHandler) SendCoins(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, dto.SendCoinsResponse{
-		Hash:      result.Hash,
-		Lt:        result.Lt,
-		Address:   result.Address,
-		Amount:    result.Amount,
-		Fee:       result.Fee,
-		Recipient: result.Recipient,
-		Comment:   result.Comment,
+	resp := dto.SendCoinsResponse{
+		ID:        tx.ID,
+		Status:    tx.Status,
+		MsgHash:   tx.MsgHash,
+		Address:   tx.FromAddress,
+		Amount:    tx.Amount,
+		Recipient: tx.ToAddress,
+		Comment:   tx.Comment,
+		Mode:      prepared.Mode,
+		Bounce:    prepared.Bounce,
+		ExpiresAt: tx.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+	}
+	// Отправка записана, но liteserver вернул ошибку: сообщение могло дойти
+	// до сети, поэтому отдаем ID для опроса статуса вместо повторной отправки
+	if err != nil {
+		resp.Error = err.Error()
+	}
+
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// GetSendStatus возвращает статус отправки
+// @Summary Статус отправки
+// @Description Возвращает отправку TON по ID. pending меняется на confirmed или failed, когда фоновый трекер находит транзакцию или истекает срок сообщения
+// @Tags wallet
+// @Produce json
+// @Param id path int true "ID кошелька"
+// @Param send_id path int true "ID отправки"
+// @Success 200 {object} dto.SendStatusResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/wallet/{id}/send/{send_id} [get]
+func (h *WalletHandler) GetSendStatus(c *gin.Context) {
+	walletID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_wallet_id",
+			Message: "ID кошелька должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	sendID, err := strconv.ParseInt(c.Param("send_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_send_id",
+			Message: "ID отправки должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	tx, err := h.walletService.GetSend(c.Request.Context(), walletID, sendID)
+	if errors.Is(err, service.ErrSendNotFound) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "send_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_get_send",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SendStatusResponse{
+		ID:        tx.ID,
+		Status:    tx.Status,
+		MsgHash:   tx.MsgHash,
+		Hash:      tx.TxHash,
+		Lt:        tx.Lt,
+		Address:   tx.FromAddress,
+		Amount:    tx.Amount,
+		Fee:       tx.Fee,
+		Recipient: tx.ToAddress,
+		Comment:   tx.Comment,
+		Error:     tx.Error,
+		ExpiresAt: tx.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+		CreatedAt: tx.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt: tx.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+
+		ComputeExitCode:  tx.ComputeExitCode,
+		ActionResultCode: tx.ActionResultCode,
+		BounceTxHash:     tx.BounceTxHash,
+	})
+}
+
+// EstimateSend оценивает комиссию перевода
+// @Summary Оценить комиссию перевода
+// @Description Собирает то же внешнее сообщение, что и отправка, и считает комиссии (прием, хранение, газ, пересылка) по ценам из конфигурации сети. Ничего не отправляет
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Param id path int true "ID кошелька"
+// @Param request body dto.SendCoinsRequest true "Данные перевода"
+// @Success 200 {object} dto.EstimateSendResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/wallet/{id}/send/estimate [post]
+func (h *WalletHandler) EstimateSend(c *gin.Context) {
+	walletIDStr := c.Param("id")
+	walletID, err := strconv.ParseInt(walletIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_wallet_id",
+			Message: "ID кошелька должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.SendCoinsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_transfer",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	// Проверяем существование кошелька
+	_, err = h.walletService.GetWalletByID(c.Request.Context(), walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "wallet_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	estimate, err := h.walletService.EstimateSend(c.Request.Context(), walletID, &service.TONTransfer{
+		Recipient:    req.Recipient,
+		Amount:       req.Amount,
+		Comment:      req.Comment,
+		Mode:         req.Mode,
+		Bounce:       req.Bounce,
+		AllowDestroy: req.AllowDestroy,
+
+		EncryptComment: req.EncryptComment,
+		Payload:        req.Payload,
+		StateInit:      req.StateInit,
 	})
+	if errors.Is(err, service.ErrWatchOnlyWallet) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "watch_only_wallet",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidTransfer) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_transfer",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_estimate_fee",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.EstimateSendResponse{
+		Address:          estimate.Address,
+		Recipient:        estimate.Recipient,
+		Mode:             estimate.Mode,
+		Bounce:           estimate.Bounce,
+		Deploy:           estimate.Deploy,
+		Balance:          estimate.Balance,
+		Amount:           estimate.Amount,
+		RecipientAmount:  estimate.RecipientAmount,
+		ImportFee:        estimate.ImportFee,
+		StorageFee:       estimate.StorageFee,
+		ComputeFee:       estimate.ComputeFee,
+		ForwardFee:       estimate.ForwardFee,
+		TotalFee:         estimate.TotalFee,
+		ResultingBalance: estimate.ResultingBalance,
+		Sufficient:       estimate.Sufficient,
+		GasUsed:          estimate.GasUsed,
+	})
+}
+
+// SendBatch отправляет TON нескольким получателям
+// @Summary Пакетная отправка TON
+// @Description Разбивает переводы на части по максимальному числу сообщений для версии кошелька (V5R1 - 255, V4R2/V3R2 - 4) и записывает каждую часть pending отправкой в очереди seqno кошелька, не дожидаясь транзакций. Статус части доступен по ее send_id через GET /api/v1/wallet/{id}/send/{send_id}; часть, не принятая сетью до expires_at, становится failed
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Param id path int true "ID кошелька"
+// @Param request body dto.SendBatchRequest true "Список переводов"
+// @Success 202 {object} dto.SendBatchResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/wallet/{id}/send-batch [post]
+func (h *WalletHandler) SendBatch(c *gin.Context) {
+	walletIDStr := c.Param("id")
+	walletID, err := strconv.ParseInt(walletIDStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_wallet_id",
+			Message: "ID кошелька должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req dto.SendBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	// Проверяем существование кошелька
+	_, err = h.walletService.GetWalletByID(c.Request.Context(), walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "wallet_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	transfers := make([]*service.TONTransfer, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		transfers = append(transfers, &service.TONTransfer{
+			Recipient: m.Recipient,
+			Amount:    m.Amount,
+			Comment:   m.Comment,
+		})
+	}
+
+	result, err := h.walletService.SendBatch(c.Request.Context(), walletID, transfers)
+	if errors.Is(err, service.ErrWatchOnlyWallet) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "watch_only_wallet",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidTransfer) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_transfer",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_send_batch",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	resp := dto.SendBatchResponse{
+		Address:  result.Address,
+		Total:    len(result.Messages),
+		Chunks:   make([]*dto.BatchChunkDTO, 0, len(result.Chunks)),
+		Messages: make([]*dto.BatchMessageDTO, 0, len(result.Messages)),
+	}
+
+	for _, chunk := range result.Chunks {
+		chunkDTO := &dto.BatchChunkDTO{
+			Index:   chunk.Index,
+			Size:    chunk.Size,
+			Status:  chunk.Status,
+			SendID:  chunk.SendID,
+			MsgHash: chunk.MsgHash,
+			Error:   chunk.Error,
+		}
+		if chunk.ExpiresAt != nil {
+			chunkDTO.ExpiresAt = chunk.ExpiresAt.Format("2006-01-02T15:04:05Z")
+		}
+		resp.Chunks = append(resp.Chunks, chunkDTO)
+	}
+
+	for _, msg := range result.Messages {
+		if msg.Status == service.BatchStatusPending {
+			resp.Pending++
+		} else {
+			resp.Failed++
+		}
+
+		resp.Messages = append(resp.Messages, &dto.BatchMessageDTO{
+			Index:     msg.Index,
+			Chunk:     msg.Chunk,
+			Recipient: msg.Recipient,
+			Amount:    msg.Amount,
+			Comment:   msg.Comment,
+			Status:    msg.Status,
+			SendID:    msg.SendID,
+			MsgHash:   msg.MsgHash,
+			Error:     msg.Error,
+		})
+	}
+
+	c.JSON(http.StatusAccepted, resp)
 }
@@ -0,0 +1,430 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"wallet_test/src/modules/wallet/dto"
+	"wallet_test/src/modules/wallet/model"
+	"wallet_test/src/modules/wallet/service"
+)
+
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateSubscription создает подписку на события
+// @Summary Создать webhook подписку
+// @Description Регистрирует адрес для событий deposit.received, send.confirmed, send.failed, send.bounced, wallet.created, wallet.deleted. Запросы подписываются заголовком X-Webhook-Signature: sha256=hex(HMAC-SHA256(secret, X-Webhook-Timestamp + "." + body)). Секрет возвращается только в этом ответе
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body dto.WebhookSubscriptionRequest true "Данные подписки"
+// @Success 201 {object} dto.WebhookSubscriptionDTO
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/webhooks [post]
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var req dto.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	sub, err := h.webhookService.CreateSubscription(c.Request.Context(), &service.WebhookSubscriptionInput{
+		URL:      req.URL,
+		Secret:   req.Secret,
+		Events:   req.Events,
+		IsActive: req.IsActive,
+	})
+	if errors.Is(err, service.ErrInvalidWebhook) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_webhook",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_create_webhook",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhookSubscriptionDTO(sub, true))
+}
+
+// ListSubscriptions возвращает подписки
+// @Summary Список webhook подписок
+// @Description Возвращает все подписки без секретов
+// @Tags webhooks
+// @Produce json
+// @Success 200 {object} dto.ListWebhookSubscriptionsResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/webhooks [get]
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	subs, err := h.webhookService.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_get_webhooks",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	items := make([]*dto.WebhookSubscriptionDTO, 0, len(subs))
+	for _, sub := range subs {
+		items = append(items, webhookSubscriptionDTO(sub, false))
+	}
+
+	c.JSON(http.StatusOK, dto.ListWebhookSubscriptionsResponse{
+		Subscriptions: items,
+		Total:         len(items),
+	})
+}
+
+// GetSubscription возвращает подписку
+// @Summary Получить webhook подписку
+// @Description Возвращает подписку без секрета
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {object} dto.WebhookSubscriptionDTO
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/v1/webhooks/{id} [get]
+func (h *WebhookHandler) GetSubscription(c *gin.Context) {
+	id, ok := subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	sub, err := h.webhookService.GetSubscription(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "webhook_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhookSubscriptionDTO(sub, false))
+}
+
+// UpdateSubscription изменяет подписку
+// @Summary Изменить webhook подписку
+// @Description Заменяет адрес и фильтр событий. Пустой secret оставляет прежний, is_active включает и отключает подписку
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param request body dto.WebhookSubscriptionRequest true "Данные подписки"
+// @Success 200 {object} dto.WebhookSubscriptionDTO
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/webhooks/{id} [put]
+func (h *WebhookHandler) UpdateSubscription(c *gin.Context) {
+	id, ok := subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	sub, err := h.webhookService.UpdateSubscription(c.Request.Context(), id, &service.WebhookSubscriptionInput{
+		URL:      req.URL,
+		Secret:   req.Secret,
+		Events:   req.Events,
+		IsActive: req.IsActive,
+	})
+	if errors.Is(err, service.ErrInvalidWebhook) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_webhook",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "webhook_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_update_webhook",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhookSubscriptionDTO(sub, req.Secret != ""))
+}
+
+// DeleteSubscription удаляет подписку
+// @Summary Удалить webhook подписку
+// @Description Удаляет подписку вместе с журналом доставок
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	id, ok := subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	err := h.webhookService.DeleteSubscription(c.Request.Context(), id)
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "webhook_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_delete_webhook",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{
+		Success: true,
+		Message: "Подписка успешно удалена",
+	})
+}
+
+// ListDeliveries возвращает журнал доставок подписки
+// @Summary Журнал доставок webhook
+// @Description Возвращает доставки событий подписки, новые первыми: статус, число попыток, HTTP статус и ошибку последней попытки
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param status query string false "Статус" Enums(pending, delivered, failed)
+// @Param limit query int false "Количество записей" default(100)
+// @Success 200 {object} dto.ListWebhookDeliveriesResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, ok := subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ListWebhookDeliveriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if req.Limit == 0 {
+		req.Limit = 100
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), id, req.Status, req.Limit)
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "webhook_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_get_deliveries",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	items := make([]*dto.WebhookDeliveryDTO, 0, len(deliveries))
+	for _, d := range deliveries {
+		items = append(items, webhookDeliveryDTO(d))
+	}
+
+	c.JSON(http.StatusOK, dto.ListWebhookDeliveriesResponse{
+		SubscriptionID: id,
+		Deliveries:     items,
+		Total:          len(items),
+	})
+}
+
+// ReplayDelivery повторяет неуспешную доставку
+// @Summary Повторить доставку webhook
+// @Description Ставит failed доставку в очередь заново с обнуленным счетчиком попыток. Тело события и его id не меняются
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param delivery path int true "ID доставки"
+// @Success 200 {object} dto.WebhookDeliveryDTO
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/v1/webhooks/{id}/deliveries/{delivery}/replay [post]
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+	id, ok := subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := strconv.ParseInt(c.Param("delivery"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_delivery_id",
+			Message: "ID доставки должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	delivery, err := h.webhookService.ReplayDelivery(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "delivery_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhookDeliveryDTO(delivery))
+}
+
+// ReplayFailed повторяет все неуспешные доставки подписки
+// @Summary Повторить неуспешные доставки webhook
+// @Description Ставит все failed доставки подписки в очередь заново
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {object} dto.ReplayWebhookDeliveriesResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/webhooks/{id}/deliveries/replay [post]
+func (h *WebhookHandler) ReplayFailed(c *gin.Context) {
+	id, ok := subscriptionID(c)
+	if !ok {
+		return
+	}
+
+	replayed, err := h.webhookService.ReplayFailed(c.Request.Context(), id)
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Error:   "webhook_not_found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error:   "failed_to_replay_deliveries",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.ReplayWebhookDeliveriesResponse{
+		SubscriptionID: id,
+		Replayed:       replayed,
+	})
+}
+
+// subscriptionID разбирает ID подписки из пути; при ошибке отвечает 400
+func subscriptionID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error:   "invalid_webhook_id",
+			Message: "ID подписки должен быть числом",
+			Code:    http.StatusBadRequest,
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+func webhookSubscriptionDTO(sub *model.WebhookSubscription, withSecret bool) *dto.WebhookSubscriptionDTO {
+	item := &dto.WebhookSubscriptionDTO{
+		ID:        sub.ID,
+		URL:       sub.URL,
+		Events:    sub.Events,
+		IsActive:  sub.IsActive,
+		CreatedAt: sub.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt: sub.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if withSecret {
+		item.Secret = sub.Secret
+	}
+	if item.Events == nil {
+		item.Events = []string{}
+	}
+	return item
+}
+
+func webhookDeliveryDTO(d *model.WebhookDelivery) *dto.WebhookDeliveryDTO {
+	item := &dto.WebhookDeliveryDTO{
+		ID:             d.ID,
+		EventID:        d.EventID,
+		Event:          d.Event,
+		Payload:        d.Payload,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		Error:          d.Error,
+		CreatedAt:      d.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if d.Status == service.DeliveryPending && !d.NextAttemptAt.IsZero() {
+		item.NextAttemptAt = d.NextAttemptAt.Format("2006-01-02T15:04:05Z")
+	}
+	if !d.DeliveredAt.IsZero() {
+		item.DeliveredAt = d.DeliveredAt.Format("2006-01-02T15:04:05Z")
+	}
+	return item
+}
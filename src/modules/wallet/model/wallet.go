@@ -9,17 +9,29 @@ import (
 type Wallet struct {
 	bun.BaseModel `bun:"table:wallets,alias:w"`
 
-	ID            int64     `bun:"id,pk,autoincrement" json:"id"`
-	UserID        int64     `bun:"user_id,notnull" json:"user_id"`
-	Address       string    `bun:"address,notnull,unique" json:"address"`
-	PublicKey     string    `bun:"public_key,notnull" json:"public_key"`
-	EncryptedSeed string    `bun:"encrypted_seed,notnull" json:"-"`
-	WalletType    string    `bun:"wallet_type,notnull" json:"wallet_type"` // V5R1Final, V4R2, etc
-	Network       string    `bun:"network,notnull" json:"network"`         // mainnet, testnet
-	IsActive      bool      `bun:"is_active,notnull,default:true" json:"is_active"`
-	CreatedAt     time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
-	UpdatedAt     time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
-	User          *User     `bun:"rel:belongs-to,join:user_id=id" json:"user,omitempty"`
+	ID                int64     `bun:"id,pk,autoincrement" json:"id"`
+	UserID            int64     `bun:"user_id,notnull" json:"user_id"`
+	Address           string    `bun:"address,notnull,unique" json:"address"`
+	PublicKey         string    `bun:"public_key,notnull" json:"public_key"`                     // ed25519, hex
+	EncryptedSeed     string    `bun:"encrypted_seed,nullzero" json:"-"`                         // пусто у watch-only кошельков
+	EncryptedPassword string    `bun:"encrypted_password,nullzero" json:"-"`                     // пароль мнемоники (для импортированных)
+	WalletType        string    `bun:"wallet_type,notnull" json:"wallet_type"`                   // V5R1Final, V4R2, V3R2, HighloadV3
+	Network           string    `bun:"network,notnull" json:"network"`                           // mainnet, testnet
+	IsWatchOnly       bool      `bun:"is_watch_only,notnull,default:false" json:"is_watch_only"` // только адрес, без seed
+	IsCold            bool      `bun:"is_cold,notnull,default:false" json:"is_cold"`             // ключ только у офлайн подписи
+	SubwalletID       *int64    `bun:"subwallet_id" json:"subwallet_id,omitempty"`               // subwallet_id (HighloadV3)
+	NetworkGlobalID   *int32    `bun:"network_global_id" json:"-"`                               // global_id, с которым выведен адрес (V5R1Final)
+	HighloadQuerySeq  int64     `bun:"highload_query_seq,notnull,default:0" json:"-"`            // счетчик выданных query_id (HighloadV3)
+	LastIndexedLt     uint64    `bun:"last_indexed_lt,notnull,default:0" json:"-"`               // lt последней проиндексированной транзакции
+	HistoryLt         uint64    `bun:"history_lt,notnull,default:0" json:"-"`                    // с этой транзакции догружается старая история, 0 - загружена
+	HistoryHash       string    `bun:"history_hash" json:"-"`                                    // хеш транзакции history_lt, base64
+	CatchupLt         uint64    `bun:"catchup_lt,notnull,default:0" json:"-"`                    // с этой транзакции догружаются новые транзакции, пропущенные за время простоя, 0 - пропуска нет
+	CatchupHash       string    `bun:"catchup_hash" json:"-"`                                    // хеш транзакции catchup_lt, base64
+	CatchupTopLt      uint64    `bun:"catchup_top_lt,notnull,default:0" json:"-"`                // lt новейшей транзакции пропуска, станет last_indexed_lt после его загрузки
+	IsActive          bool      `bun:"is_active,notnull,default:true" json:"is_active"`
+	CreatedAt         time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt         time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+	User              *User     `bun:"rel:belongs-to,join:user_id=id" json:"user,omitempty"`
 }
 
 type User struct {
@@ -34,17 +46,100 @@ type User struct {
 }
 
 type Transaction struct {
-	bun.BaseModel `bun:"table:transactions,alias:t"`
+	bun.BaseModel    `bun:"table:transactions,alias:t"`
+	ID               int64           `bun:"id,pk,autoincrement" json:"id"`
+	WalletID         int64           `bun:"wallet_id,notnull" json:"wallet_id"`
+	TxHash           string          `bun:"tx_hash,unique,nullzero" json:"tx_hash,omitempty"`   // пусто, пока транзакция не найдена
+	MsgHash          string          `bun:"msg_hash,unique,nullzero" json:"msg_hash,omitempty"` // хеш тела внешнего сообщения (hex) у отправок сервиса
+	NormHash         string          `bun:"norm_hash,nullzero" json:"-"`                        // нормализованный хеш внешнего сообщения (TEP-467), hex
+	Lt               uint64          `bun:"lt,nullzero" json:"lt"`                              // пусто, пока транзакция не найдена
+	FromAddress      string          `bun:"from_address,notnull" json:"from_address"`
+	ToAddress        string          `bun:"to_address,notnull" json:"to_address"`
+	Amount           string          `bun:"amount,notnull" json:"amount"` // храним как string для точности
+	Fee              string          `bun:"fee" json:"fee"`
+	Status           string          `bun:"status,notnull" json:"status"` // pending, confirmed, failed, bounced
+	BlockNumber      int64           `bun:"block_number" json:"block_number"`
+	Comment          string          `bun:"comment" json:"comment"`
+	Direction        string          `bun:"direction" json:"direction"`                // in, out
+	TxTime           time.Time       `bun:"tx_time,nullzero" json:"tx_time,omitempty"` // время транзакции в блокчейне
+	Jetton           *JettonTransfer `bun:"jetton,type:jsonb" json:"jetton,omitempty"` // перевод жетонов (TEP-74), если распознан
+	Messages         []*TxMessage    `bun:"messages,type:jsonb" json:"messages,omitempty"`
+	NetAmount        string          `bun:"net_amount" json:"net_amount,omitempty"` // изменение баланса в TON со знаком
+	Error            string          `bun:"error" json:"error,omitempty"`           // причина failed
+	ComputeExitCode  *int32          `bun:"compute_exit_code" json:"compute_exit_code,omitempty"`
+	ActionResultCode *int32          `bun:"action_result_code" json:"action_result_code,omitempty"`
+	Aborted          bool            `bun:"aborted,notnull,default:false" json:"aborted,omitempty"`
+	Bounced          bool            `bun:"bounced,notnull,default:false" json:"bounced,omitempty"`  // входящий возврат bounce
+	BounceLt         uint64          `bun:"bounce_lt,nullzero" json:"-"`                             // created_lt bounce сообщения
+	BounceOf         string          `bun:"bounce_of,nullzero" json:"bounce_of,omitempty"`           // у возврата: хеш исходной транзакции
+	BounceTxHash     string          `bun:"bounce_tx_hash,nullzero" json:"bounce_tx_hash,omitempty"` // у исходной: хеш транзакции возврата
+	ExpiresAt        time.Time       `bun:"expires_at,nullzero" json:"expires_at,omitempty"`         // valid_until внешнего сообщения
+	Seqno            *int64          `bun:"seqno" json:"-"`                                          // seqno pending отправки кошельков с seqno
+	Boc              string          `bun:"boc,nullzero" json:"-"`                                   // подписанное внешнее сообщение для повторной отправки, base64
+	CreatedAt        time.Time       `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt        time.Time       `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+	Wallet           *Wallet         `bun:"rel:belongs-to,join:wallet_id=id" json:"wallet,omitempty"`
+}
+
+// JettonTransfer - распознанный в транзакции перевод жетонов
+type JettonTransfer struct {
+	Direction    string `json:"direction"`        // "in" или "out"
+	QueryID      uint64 `json:"query_id"`         // query_id перевода
+	Amount       string `json:"amount"`           // в минимальных единицах
+	Counterparty string `json:"counterparty"`     // отправитель (in) или получатель (out)
+	JettonWallet string `json:"jetton_wallet"`    // наш jetton-кошелек
+	Master       string `json:"master,omitempty"` // jetton master проверенного входящего уведомления
+	Comment      string `json:"comment,omitempty"`
+}
+
+// TxMessage - входящее или исходящее внутреннее сообщение транзакции
+type TxMessage struct {
+	Direction    string          `json:"direction"`           // "in" или "out"
+	Counterparty string          `json:"counterparty"`        // отправитель (in) или получатель (out)
+	Amount       string          `json:"amount"`              // в TON
+	Op           string          `json:"op,omitempty"`        // опкод тела (hex), если есть
+	Comment      string          `json:"comment,omitempty"`   // текстовый комментарий
+	Encrypted    bool            `json:"encrypted,omitempty"` // комментарий зашифрован (op 0x2167da4b); расшифрованный текст хранится в Comment открытым
+	Bounce       bool            `json:"bounce,omitempty"`    // флаг bounce исходящего сообщения
+	Bounced      bool            `json:"bounced,omitempty"`   // входящий возврат bounce
+	BodyHead     string          `json:"body_head,omitempty"` // первые 256 бит тела (у возврата - после 0xffffffff): "бит:hex"
+	Jetton       *JettonTransfer `json:"jetton,omitempty"`    // перевод жетонов (TEP-74), если распознан
+}
+
+// Deposit - входящий перевод TON на кошелек сервиса. Записывается один раз
+// по tx_hash вместе с событием deposit.received.
+type Deposit struct {
+	bun.BaseModel `bun:"table:deposits,alias:d"`
+
+	ID        int64           `bun:"id,pk,autoincrement" json:"id"`
+	WalletID  int64           `bun:"wallet_id,notnull" json:"wallet_id"`
+	TxHash    string          `bun:"tx_hash,unique,notnull" json:"tx_hash"` // base64
+	Lt        uint64          `bun:"lt,notnull" json:"lt"`
+	Amount    string          `bun:"amount,notnull" json:"amount"` // в TON
+	Sender    string          `bun:"sender,notnull" json:"sender"`
+	Comment   string          `bun:"comment" json:"comment,omitempty"`
+	Jetton    *JettonTransfer `bun:"jetton,type:jsonb" json:"jetton,omitempty"`
+	TxTime    time.Time       `bun:"tx_time,notnull" json:"tx_time"`
+	CreatedAt time.Time       `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	Wallet    *Wallet         `bun:"rel:belongs-to,join:wallet_id=id" json:"wallet,omitempty"`
+}
+
+// HighloadQuery - внешнее сообщение highload v3 кошелька с выданным query_id.
+// Подписанное сообщение хранится, чтобы повторная отправка не создавала новый перевод.
+type HighloadQuery struct {
+	bun.BaseModel `bun:"table:highload_queries,alias:hq"`
 	ID            int64     `bun:"id,pk,autoincrement" json:"id"`
 	WalletID      int64     `bun:"wallet_id,notnull" json:"wallet_id"`
-	TxHash        string    `bun:"tx_hash,unique,notnull" json:"tx_hash"`
-	FromAddress   string    `bun:"from_address,notnull" json:"from_address"`
-	ToAddress     string    `bun:"to_address,notnull" json:"to_address"`
-	Amount        string    `bun:"amount,notnull" json:"amount"` // храним как string для точности
-	Fee           string    `bun:"fee" json:"fee"`
-	Status        string    `bun:"status,notnull" json:"status"` // pending, confirmed, failed
-	BlockNumber   int64     `bun:"block_number" json:"block_number"`
-	Comment       string    `bun:"comment" json:"comment"`
+	QueryID       int64     `bun:"query_id,notnull" json:"query_id"`             // 23 бита: shift и bit_number
+	MsgCreatedAt  int64     `bun:"msg_created_at,notnull" json:"msg_created_at"` // created_at внешнего сообщения (unix)
+	ExpiresAt     time.Time `bun:"expires_at,notnull" json:"expires_at"`         // после этого контракт не примет сообщение
+	MsgHash       string    `bun:"msg_hash,unique,notnull" json:"msg_hash"`      // хеш тела внешнего сообщения, hex
+	Boc           string    `bun:"boc,notnull" json:"-"`                         // подписанное внешнее сообщение, base64
+	Messages      int       `bun:"messages,notnull" json:"messages"`             // число внутренних сообщений
+	Status        string    `bun:"status,notnull" json:"status"`                 // pending, processed, expired
+	TxHash        string    `bun:"tx_hash" json:"tx_hash,omitempty"`
+	Error         string    `bun:"error" json:"error,omitempty"` // последняя ошибка отправки
 	CreatedAt     time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt     time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
 	Wallet        *Wallet   `bun:"rel:belongs-to,join:wallet_id=id" json:"wallet,omitempty"`
 }
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// WebhookSubscription - адрес, на который отправляются события.
+// Пустой список events - подписка на все события.
+type WebhookSubscription struct {
+	bun.BaseModel `bun:"table:webhook_subscriptions,alias:ws"`
+
+	ID        int64     `bun:"id,pk,autoincrement" json:"id"`
+	URL       string    `bun:"url,notnull" json:"url"`
+	Secret    string    `bun:"secret,notnull" json:"-"` // ключ HMAC-SHA256 подписи
+	Events    []string  `bun:"events,array" json:"events"`
+	IsActive  bool      `bun:"is_active,notnull,default:true" json:"is_active"`
+	CreatedAt time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}
+
+// WebhookDelivery - доставка одного события на одну подписку.
+// Ожидающие доставки повторяются с экспоненциальной задержкой.
+type WebhookDelivery struct {
+	bun.BaseModel `bun:"table:webhook_deliveries,alias:wd"`
+
+	ID             int64           `bun:"id,pk,autoincrement" json:"id"`
+	SubscriptionID int64           `bun:"subscription_id,notnull" json:"subscription_id"`
+	EventID        string          `bun:"event_id,notnull" json:"event_id"` // общий для всех доставок события
+	Event          string          `bun:"event,notnull" json:"event"`
+	Payload        json.RawMessage `bun:"payload,type:jsonb,notnull" json:"payload"`
+	Status         string          `bun:"status,notnull" json:"status"` // pending, delivered, failed
+	Attempts       int             `bun:"attempts,notnull,default:0" json:"attempts"`
+	NextAttemptAt  time.Time       `bun:"next_attempt_at,nullzero" json:"next_attempt_at,omitempty"`
+	ResponseStatus int             `bun:"response_status" json:"response_status,omitempty"` // HTTP статус последней попытки
+	Error          string          `bun:"error" json:"error,omitempty"`                     // ошибка последней попытки
+	DeliveredAt    time.Time       `bun:"delivered_at,nullzero" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `bun:"created_at,nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt      time.Time       `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+
+	Subscription *WebhookSubscription `bun:"rel:belongs-to,join:subscription_id=id" json:"-"`
+}
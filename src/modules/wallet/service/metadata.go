@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xssnick/tonutils-go/ton/nft"
+)
+
+// maxMetadataSize - ограничение размера offchain JSON с метаданными
+const maxMetadataSize = 1 << 20
+
+// metadataMaxRedirects - сколько перенаправлений допускается при загрузке метаданных
+const metadataMaxRedirects = 3
+
+// cgnatPrefix - shared address space (RFC 6598), не является публичным
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// metadataClient загружает метаданные по ссылке из контракта. Ссылку задает
+// кто угодно, поэтому соединения разрешены только с публичными адресами,
+// а прокси из окружения не используется.
+var metadataClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		Proxy:                  nil,
+		DialContext:            dialPublic,
+		TLSHandshakeTimeout:    5 * time.Second,
+		ResponseHeaderTimeout:  5 * time.Second,
+		MaxResponseHeaderBytes: 1 << 16,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= metadataMaxRedirects {
+			return errors.New("too many redirects")
+		}
+		if req.URL.Scheme != "https" {
+			return fmt.Errorf("redirect to %s url is not allowed", req.URL.Scheme)
+		}
+		return nil
+	},
+}
+
+var metadataDialer = &net.Dialer{Timeout: 5 * time.Second}
+
+// dialPublic разрешает имя и соединяется с первым адресом, только если все
+// адреса имени публичные: так ссылка не ведет во внутреннюю сеть или к
+// metadata сервису облака, а повторное разрешение имени не подменит адрес
+func dialPublic(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses for %s", host)
+	}
+
+	for _, ip := range ips {
+		if !isPublicIP(ip) {
+			return nil, fmt.Errorf("address %s of %s is not public", ip, host)
+		}
+	}
+
+	return metadataDialer.DialContext(ctx, network, net.JoinHostPort(ips[0].Unmap().String(), port))
+}
+
+func isPublicIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() &&
+		!ip.IsPrivate() &&
+		!ip.IsLoopback() &&
+		!ip.IsLinkLocalUnicast() &&
+		!cgnatPrefix.Contains(ip)
+}
+
+// ContentMetadata - метаданные жетона или NFT (TEP-64)
+type ContentMetadata struct {
+	URI         string `json:"uri,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Symbol      string `json:"symbol,omitempty"`
+	Image       string `json:"image,omitempty"`
+	Decimals    string `json:"decimals,omitempty"`
+}
+
+// offchainMetadata - формат JSON по ссылке из offchain контента.
+// decimals встречается и строкой, и числом.
+type offchainMetadata struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Symbol      string          `json:"symbol"`
+	Image       string          `json:"image"`
+	Decimals    json.RawMessage `json:"decimals"`
+}
+
+// resolveContent собирает метаданные из onchain словаря и/или offchain JSON.
+// Onchain значения приоритетнее offchain (semichain контент).
+func resolveContent(ctx context.Context, content nft.ContentAny) (*ContentMetadata, error) {
+	meta := &ContentMetadata{}
+
+	switch c := content.(type) {
+	case *nft.ContentOffchain:
+		meta.URI = c.URI
+		if err := fetchOffchainMetadata(ctx, meta); err != nil {
+			return nil, err
+		}
+	case *nft.ContentSemichain:
+		meta.URI = c.URI
+		if err := fetchOffchainMetadata(ctx, meta); err != nil {
+			return nil, err
+		}
+		applyOnchainMetadata(meta, &c.ContentOnchain)
+	case *nft.ContentOnchain:
+		applyOnchainMetadata(meta, c)
+	}
+
+	return meta, nil
+}
+
+func applyOnchainMetadata(meta *ContentMetadata, c *nft.ContentOnchain) {
+	for key, dst := range map[string]*string{
+		"name":        &meta.Name,
+		"description": &meta.Description,
+		"symbol":      &meta.Symbol,
+		"image":       &meta.Image,
+		"decimals":    &meta.Decimals,
+	} {
+		if v := c.GetAttribute(key); v != "" {
+			*dst = v
+		}
+	}
+}
+
+func fetchOffchainMetadata(ctx context.Context, meta *ContentMetadata) error {
+	if meta.URI == "" {
+		return nil
+	}
+
+	metaURL, err := metadataURL(meta.URI)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metaURL, nil)
+	if err != nil {
+		return fmt.Errorf("invalid metadata uri: %w", err)
+	}
+
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch metadata: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch metadata: status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxMetadataSize {
+		return fmt.Errorf("metadata is too large: %d bytes", resp.ContentLength)
+	}
+
+	var data offchainMetadata
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxMetadataSize)).Decode(&data); err != nil {
+		return fmt.Errorf("failed to decode metadata: %w", err)
+	}
+
+	meta.Name = data.Name
+	meta.Description = data.Description
+	meta.Symbol = data.Symbol
+	meta.Image = data.Image
+	meta.Decimals = strings.Trim(string(data.Decimals), `"`)
+
+	return nil
+}
+
+// metadataURL переводит ipfs:// ссылки на публичный HTTP шлюз.
+// Кроме ipfs допускаются только https ссылки.
+func metadataURL(uri string) (string, error) {
+	if cid, ok := strings.CutPrefix(uri, "ipfs://"); ok {
+		return "https://ipfs.io/ipfs/" + cid, nil
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return "", fmt.Errorf("unsupported metadata uri %q: only https and ipfs are allowed", uri)
+	}
+
+	return uri, nil
+}
+
+// decimals возвращает количество знаков после запятой, по умолчанию 9 (TEP-64)
+func (m *ContentMetadata) decimals() int {
+	d, err := strconv.Atoi(m.Decimals)
+	if err != nil || d < 0 || d > 255 {
+		return 9
+	}
+	return d
+}
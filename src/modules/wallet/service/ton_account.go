@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+)
+
+// AccountInfo - состояние произвольного аккаунта в блокчейне
+type AccountInfo struct {
+	Address       string `json:"address"`        // bounceable форма
+	NonBounceable string `json:"non_bounceable"` // non-bounceable форма
+	RawAddress    string `json:"raw_address"`    // workchain:hex
+	Bounceable    bool   `json:"bounceable"`     // флаг bounceable у запрошенного адреса
+	TestnetOnly   bool   `json:"testnet_only"`   // флаг testnet у запрошенного адреса
+	Status        string `json:"status"`         // uninit, active, frozen, nonexist
+	SendBounce    bool   `json:"send_bounce"`    // рекомендуемый bounce флаг перевода: только активному контракту
+	Balance       string `json:"balance"`
+	LastTxLt      uint64 `json:"last_tx_lt"`
+	LastTxHash    string `json:"last_tx_hash"` // base64
+	CodeHash      string `json:"code_hash"`    // hex
+	DataHash      string `json:"data_hash"`    // hex
+	FrozenHash    string `json:"frozen_hash"`  // хеш состояния frozen аккаунта (hex)
+	Interface     string `json:"interface"`    // версия кошелька по коду контракта, пусто - не кошелек
+	WalletType    string `json:"wallet_type"`  // тип из реестра сервиса, пусто - версия не поддерживается
+	PublicKey     string `json:"public_key"`   // get_public_key кошелька (hex)
+	Seqno         *int64 `json:"seqno"`        // seqno кошелька, nil - нет get-метода
+	WalletID      int64  `json:"wallet_id"`    // кошелек сервиса с этим адресом, 0 - не наш
+}
+
+// GetAccount читает состояние любого аккаунта и определяет, является ли
+// он известным кошельком
+func (s *TONService) GetAccount(ctx context.Context, rawAddr, network string) (*AccountInfo, error) {
+	net, err := s.network(network)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := address.ParseAddr(rawAddr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+	}
+
+	block, err := net.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	acc, err := net.api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	info := &AccountInfo{
+		Address:       addr.Bounce(true).String(),
+		NonBounceable: addr.Bounce(false).String(),
+		RawAddress:    rawAddress(addr),
+		Bounceable:    addr.IsBounceable(),
+		TestnetOnly:   addr.IsTestnetOnly(),
+		Status:        AccountStatus(acc),
+		Balance:       "0",
+	}
+
+	if !acc.IsActive || acc.State == nil {
+		return info, nil
+	}
+
+	info.Balance = acc.State.Balance.String()
+	info.LastTxLt = acc.LastTxLT
+	if acc.LastTxHash != nil {
+		info.LastTxHash = base64.StdEncoding.EncodeToString(acc.LastTxHash)
+	}
+	if acc.Code != nil {
+		info.CodeHash = hex.EncodeToString(acc.Code.Hash())
+	}
+	if acc.Data != nil {
+		info.DataHash = hex.EncodeToString(acc.Data.Hash())
+	}
+	if acc.State.Status == tlb.AccountStatusFrozen && acc.State.StateHash != nil {
+		info.FrozenHash = hex.EncodeToString(acc.State.StateHash)
+	}
+
+	// Перевод с bounce неразвернутому контракту вернется отправителю
+	info.SendBounce = acc.State.Status == tlb.AccountStatusActive
+
+	info.WalletType = DetectWalletType(acc)
+	info.Interface = info.WalletType
+	if info.Interface == "" {
+		if version := wallet.GetWalletVersion(acc); version != wallet.Unknown {
+			info.Interface = version.String()
+		}
+	}
+	if info.Interface == "" {
+		return info, nil
+	}
+
+	// Get-методы необязательны для ответа, поэтому их ошибки игнорируем
+	if key, err := wallet.GetPublicKey(ctx, net.api, addr); err == nil {
+		info.PublicKey = hex.EncodeToString(key)
+	}
+	if walletHasSeqno(info.WalletType) {
+		if seqno, err := getSeqno(ctx, net.api, block, addr); err == nil {
+			value := int64(seqno)
+			info.Seqno = &value
+		}
+	}
+
+	return info, nil
+}
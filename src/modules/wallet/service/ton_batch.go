@@ -0,0 +1,39 @@
+package service
+
+import "time"
+
+// Статусы сообщений и частей пакетной отправки
+const (
+	BatchStatusPending = "pending" // записана pending отправкой, итог - по send_id
+	BatchStatusFailed  = "failed"  // сообщение не подписано и не отправлялось
+	BatchStatusSkipped = "skipped" // не отправлялось из-за ошибки в предыдущей части
+)
+
+// BatchChunkResult - одно внешнее сообщение пакетной отправки
+type BatchChunkResult struct {
+	Index     int        `json:"index"`
+	Size      int        `json:"size"`
+	Status    string     `json:"status"`
+	SendID    int64      `json:"send_id,omitempty"`  // pending отправка части
+	MsgHash   string     `json:"msg_hash,omitempty"` // хеш тела внешнего сообщения, hex
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+type BatchMessageResult struct {
+	Index     int    `json:"index"`
+	Chunk     int    `json:"chunk"`
+	Recipient string `json:"recipient"`
+	Amount    string `json:"amount"`
+	Comment   string `json:"comment,omitempty"`
+	Status    string `json:"status"`
+	SendID    int64  `json:"send_id,omitempty"`
+	MsgHash   string `json:"msg_hash,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type BatchSendResult struct {
+	Address  string                `json:"address"`
+	Chunks   []*BatchChunkResult   `json:"chunks"`
+	Messages []*BatchMessageResult `json:"messages"`
+}
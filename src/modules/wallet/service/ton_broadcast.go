@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// Опкод внешнего сообщения wallet v5 ("sign")
+const walletV5OpSign = 0x7369676e
+
+var ErrInvalidExternalMessage = errors.New("invalid external message")
+
+// errWalletKeyUnknown - у кошелька сервиса нет сохраненного публичного ключа
+var errWalletKeyUnknown = errors.New("wallet public key is unknown")
+
+// ExternalMessage - разобранное подписанное внешнее сообщение
+type ExternalMessage struct {
+	Network     string
+	Destination *address.Address
+	Hash        string // нормализованный хеш сообщения (hex), TEP-467
+	MsgHash     string // хеш тела (hex), по нему отслеживаются отправки
+
+	ext *tlb.ExternalMessage
+	net *tonNetwork
+}
+
+// ParseExternalMessage разбирает внешнее сообщение из base64 BOC
+func (s *TONService) ParseExternalMessage(boc, network string) (*ExternalMessage, error) {
+	net, err := s.network(network)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := parseBOC(boc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExternalMessage, err)
+	}
+
+	var ext tlb.ExternalMessage
+	if err := tlb.LoadFromCell(&ext, c.BeginParse()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExternalMessage, err)
+	}
+
+	if ext.DstAddr == nil || ext.DstAddr.IsAddrNone() {
+		return nil, fmt.Errorf("%w: destination address is empty", ErrInvalidExternalMessage)
+	}
+	if ext.Body == nil {
+		return nil, fmt.Errorf("%w: message body is empty", ErrInvalidExternalMessage)
+	}
+
+	return &ExternalMessage{
+		Network:     network,
+		Destination: ext.DstAddr,
+		Hash:        hex.EncodeToString(normalizedMessageHash(&ext)),
+		MsgHash:     hex.EncodeToString(ext.Body.Hash()),
+		ext:         &ext,
+		net:         net,
+	}, nil
+}
+
+// BroadcastExternal отправляет внешнее сообщение, не дожидаясь транзакции
+func (s *TONService) BroadcastExternal(ctx context.Context, msg *ExternalMessage) error {
+	if err := msg.net.api.SendExternalMessage(ctx, msg.ext); err != nil {
+		return fmt.Errorf("failed to broadcast message: %w", err)
+	}
+	return nil
+}
+
+// normalizedMessageHash считает хеш внешнего сообщения без полей, которые
+// может изменить отправитель или liteserver (TEP-467): src и import_fee
+// обнуляются, state-init отбрасывается, тело хранится ссылкой
+func normalizedMessageHash(ext *tlb.ExternalMessage) []byte {
+	return cell.BeginCell().
+		MustStoreUInt(0b10, 2).
+		MustStoreAddr(nil).
+		MustStoreAddr(ext.DstAddr).
+		MustStoreCoins(0).
+		MustStoreBoolBit(false).
+		MustStoreBoolBit(true).
+		MustStoreRef(ext.Body).
+		EndCell().
+		Hash()
+}
+
+// walletExternalBody - поля тела внешнего сообщения кошелька
+type walletExternalBody struct {
+	ValidUntil time.Time
+	Seqno      *int64 // nil у highload v3: у него нет seqno
+	signature  []byte
+	signed     *cell.Cell // подписанная часть тела
+}
+
+// parseWalletExternal разбирает тело внешнего сообщения кошелька указанного
+// типа: срок действия, seqno, подпись и подписанную часть
+func parseWalletExternal(walletType string, body *cell.Cell) (*walletExternalBody, error) {
+	result := &walletExternalBody{}
+	slice := body.BeginParse()
+
+	var err error
+	switch walletType {
+	case WalletTypeV3R2, WalletTypeV4R2:
+		// signature, затем subwallet_id, valid_until, seqno, ...
+		if result.signature, err = slice.LoadSlice(512); err != nil {
+			return nil, err
+		}
+		if result.signed, err = slice.ToCell(); err != nil {
+			return nil, err
+		}
+
+		payload := result.signed.BeginParse()
+		if _, err := payload.LoadUInt(32); err != nil {
+			return nil, err
+		}
+		validUntil, err := payload.LoadUInt(32)
+		if err != nil {
+			return nil, err
+		}
+		seqno, err := payload.LoadUInt(32)
+		if err != nil {
+			return nil, err
+		}
+		value := int64(seqno)
+		result.ValidUntil, result.Seqno = time.Unix(int64(validUntil), 0), &value
+	case WalletTypeV5R1Final:
+		// op, wallet_id, valid_until, seqno, actions, затем signature в конце
+		size := body.BitsSize()
+		if size < 512+96 {
+			return nil, errors.New("message body is too short")
+		}
+		data, err := slice.LoadSlice(size - 512)
+		if err != nil {
+			return nil, err
+		}
+		if result.signature, err = slice.LoadSlice(512); err != nil {
+			return nil, err
+		}
+
+		signed := cell.BeginCell().MustStoreSlice(data, size-512)
+		for i := 0; i < int(body.RefsNum()); i++ {
+			ref, err := body.PeekRef(i)
+			if err != nil {
+				return nil, err
+			}
+			signed.MustStoreRef(ref)
+		}
+		result.signed = signed.EndCell()
+
+		payload := result.signed.BeginParse()
+		if op, err := payload.LoadUInt(32); err != nil || op != walletV5OpSign {
+			return nil, errors.New("not a signed wallet v5 message")
+		}
+		if _, err := payload.LoadUInt(32); err != nil {
+			return nil, err
+		}
+		validUntil, err := payload.LoadUInt(32)
+		if err != nil {
+			return nil, err
+		}
+		seqno, err := payload.LoadUInt(32)
+		if err != nil {
+			return nil, err
+		}
+		value := int64(seqno)
+		result.ValidUntil, result.Seqno = time.Unix(int64(validUntil), 0), &value
+	case WalletTypeHighloadV3:
+		// signature и ссылка на subwallet_id, message, mode, query_id, created_at, timeout
+		if result.signature, err = slice.LoadSlice(512); err != nil {
+			return nil, err
+		}
+		if result.signed, err = slice.LoadRefCell(); err != nil {
+			return nil, err
+		}
+
+		payload := result.signed.BeginParse()
+		if _, err := payload.LoadUInt(32); err != nil {
+			return nil, err
+		}
+		if _, err := payload.LoadRefCell(); err != nil {
+			return nil, err
+		}
+		if _, err := payload.LoadUInt(8 + 23); err != nil {
+			return nil, err
+		}
+		createdAt, err := payload.LoadUInt(64)
+		if err != nil {
+			return nil, err
+		}
+		timeout, err := payload.LoadUInt(22)
+		if err != nil {
+			return nil, err
+		}
+		result.ValidUntil = time.Unix(int64(createdAt+timeout), 0)
+	default:
+		return nil, fmt.Errorf("unsupported wallet type: %s", walletType)
+	}
+
+	return result, nil
+}
+
+// verifyWalletExternal проверяет подпись тела ключом кошелька из БД.
+// Без ключа возвращает errWalletKeyUnknown: подпись проверить нельзя.
+func verifyWalletExternal(stored *model.Wallet, body *walletExternalBody) error {
+	key, err := hex.DecodeString(stored.PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: %s", errWalletKeyUnknown, stored.Address)
+	}
+
+	if !ed25519.Verify(key, body.signed.Hash(), body.signature) {
+		return fmt.Errorf("%w: signature does not match wallet public key", ErrInvalidExternalMessage)
+	}
+	return nil
+}
+
+// ValidateWalletExternal проверяет, что сообщение подписано ключом кошелька
+// сервиса и еще не истекло. Возвращает срок действия сообщения и его seqno
+// (nil у highload v3).
+func (s *TONService) ValidateWalletExternal(stored *model.Wallet, msg *ExternalMessage) (time.Time, *int64, error) {
+	body, err := parseWalletExternal(stored.WalletType, msg.ext.Body)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("%w: %v", ErrInvalidExternalMessage, err)
+	}
+
+	if err := verifyWalletExternal(stored, body); err != nil {
+		return time.Time{}, nil, err
+	}
+
+	if time.Now().After(body.ValidUntil) {
+		return time.Time{}, nil, fmt.Errorf("%w: message expired at %s", ErrInvalidExternalMessage, body.ValidUntil.UTC().Format(time.RFC3339))
+	}
+
+	return body.ValidUntil, body.Seqno, nil
+}
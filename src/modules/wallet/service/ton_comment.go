@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// encryptedComment - зашифрованный комментарий входящего сообщения,
+// который расшифровывается ключом кошелька в DecryptComments
+type encryptedComment struct {
+	msg    *TxMessageInfo
+	body   *cell.Cell
+	sender *address.Address
+}
+
+// encryptComment заменяет тело сообщения комментарием, зашифрованным
+// для публичного ключа получателя (get_public_key его контракта)
+func (s *TONService) encryptComment(ctx context.Context, stored *model.Wallet, seed *Seed, msg *wallet.Message, comment string) error {
+	w, net, err := s.openWallet(stored, seed)
+	if err != nil {
+		return err
+	}
+
+	theirKey, err := wallet.GetPublicKey(ctx, net.api, msg.InternalMessage.DstAddr)
+	if err != nil {
+		return fmt.Errorf("%w: recipient public key is unavailable: %v", ErrInvalidTransfer, err)
+	}
+
+	body, err := wallet.CreateEncryptedCommentCell(comment, w.WalletAddress(), w.PrivateKey(), theirKey)
+	if err != nil {
+		return fmt.Errorf("%w: failed to encrypt comment: %v", ErrInvalidTransfer, err)
+	}
+
+	msg.InternalMessage.Body = body
+	return nil
+}
+
+// HasEncryptedComments - есть ли среди транзакций входящие зашифрованные комментарии
+func HasEncryptedComments(transactions []*TransactionInfo) bool {
+	for _, info := range transactions {
+		if info.encrypted != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// DecryptComments расшифровывает входящие зашифрованные комментарии ключом
+// кошелька и публичным ключом отправителя. Комментарии, которые расшифровать
+// нельзя (у отправителя нет get_public_key, сообщение не для этого ключа),
+// остаются пустыми с признаком encrypted. Ошибка запроса к liteserver
+// возвращается, чтобы индексатор повторил проход, а не сохранил пустой
+// комментарий навсегда. Расшифрованный текст индексатор хранит открытым
+// в transactions.messages, transactions.comment и deposits.comment.
+func (s *TONService) DecryptComments(ctx context.Context, stored *model.Wallet, seed *Seed, transactions []*TransactionInfo) error {
+	if !HasEncryptedComments(transactions) {
+		return nil
+	}
+
+	w, net, err := s.openWallet(stored, seed)
+	if err != nil {
+		return err
+	}
+
+	keys := map[string]ed25519.PublicKey{}
+	for _, info := range transactions {
+		enc := info.encrypted
+		if enc == nil {
+			continue
+		}
+
+		raw := rawAddress(enc.sender)
+		theirKey, ok := keys[raw]
+		if !ok {
+			theirKey, err = senderPublicKey(ctx, net, enc.sender)
+			if err != nil {
+				return err
+			}
+			keys[raw] = theirKey
+		}
+		if theirKey == nil {
+			continue
+		}
+
+		text, err := wallet.DecryptCommentCell(enc.body, enc.sender, w.PrivateKey(), theirKey)
+		if err != nil {
+			continue
+		}
+
+		enc.msg.Comment = string(text)
+		if info.Type == "in" {
+			info.Comment = enc.msg.Comment
+		}
+	}
+
+	return nil
+}
+
+// senderPublicKey вызывает get_public_key контракта отправителя.
+// nil без ошибки - у контракта нет ключа (метода нет или аккаунт не активен).
+func senderPublicKey(ctx context.Context, net *tonNetwork, addr *address.Address) (ed25519.PublicKey, error) {
+	block, err := net.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	res, err := net.api.WaitForBlock(block.SeqNo).RunGetMethod(ctx, block, addr, "get_public_key")
+	var execErr ton.ContractExecError
+	if errors.As(err, &execErr) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to run get_public_key: %w", err)
+	}
+
+	key, err := res.Int(0)
+	if err != nil || key.Sign() < 0 || key.BitLen() > 8*ed25519.PublicKeySize {
+		return nil, nil
+	}
+
+	return key.FillBytes(make([]byte, ed25519.PublicKeySize)), nil
+}
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// Параметры конфигурации сети с ценами
+const (
+	configStoragePrices   = 18
+	configGasPricesMc     = 20
+	configGasPricesBc     = 21
+	configForwardPricesMc = 24
+	configForwardPricesBc = 25
+)
+
+// walletTransferGas - типичный расход газа контрактом кошелька на внешнее
+// сообщение с одним переводом. Точное значение дает только эмуляция,
+// поэтому compute fee - оценка.
+var walletTransferGas = map[string]int64{
+	WalletTypeV5R1Final:  5000,
+	WalletTypeV4R2:       3400,
+	WalletTypeV3R2:       3000,
+	WalletTypeHighloadV3: 5500,
+}
+
+// FeeEstimate - оценка комиссий перевода без отправки. Суммы в TON.
+type FeeEstimate struct {
+	Address          string `json:"address"`
+	Recipient        string `json:"recipient"`
+	Mode             uint8  `json:"mode"`
+	Bounce           bool   `json:"bounce"`
+	Deploy           bool   `json:"deploy"`            // внешнее сообщение содержит state-init кошелька
+	Balance          string `json:"balance"`           // текущий баланс
+	Amount           string `json:"amount"`            // сумма исходящего сообщения
+	RecipientAmount  string `json:"recipient_amount"`  // дойдет до получателя за вычетом forward fee
+	ImportFee        string `json:"import_fee"`        // прием внешнего сообщения
+	StorageFee       string `json:"storage_fee"`       // хранение с последней оплаты
+	ComputeFee       string `json:"compute_fee"`       // газ кошелька (оценка)
+	ForwardFee       string `json:"forward_fee"`       // пересылка исходящего сообщения
+	TotalFee         string `json:"total_fee"`         // сумма комиссий
+	ResultingBalance string `json:"resulting_balance"` // отрицательный, если средств не хватает
+	Sufficient       bool   `json:"sufficient"`        // баланс покрывает сумму и комиссии
+	GasUsed          int64  `json:"gas_used"`          // оценка расхода газа
+}
+
+type msgForwardPrices struct {
+	lumpPrice uint64
+	bitPrice  uint64
+	cellPrice uint64
+}
+
+type gasPrices struct {
+	gasPrice     uint64 // nanoton * 2^16 за единицу газа
+	flatGasLimit uint64
+	flatGasPrice uint64
+}
+
+type storagePrices struct {
+	bitPrice  uint64 // nanoton * 2^16 за бит в секунду
+	cellPrice uint64
+}
+
+// EstimateTransfer собирает то же внешнее сообщение, что и SendTransaction,
+// и считает комиссии по ценам из конфигурации сети. Сообщение не отправляется.
+func (s *TONService) EstimateTransfer(ctx context.Context, stored *model.Wallet, seed *Seed, transfer *TONTransfer) (*FeeEstimate, error) {
+	msg, err := transferMessage(transfer)
+	if err != nil {
+		return nil, err
+	}
+
+	if transfer.EncryptComment {
+		if err := s.encryptComment(ctx, stored, seed, msg, transfer.Comment); err != nil {
+			return nil, err
+		}
+	}
+
+	w, net, err := s.openWallet(stored, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Highload v3 требует query_id; для оценки он не выделяется
+	if stored.WalletType == WalletTypeHighloadV3 {
+		w, err = deriveWallet(net, stored, seed, wallet.ConfigHighloadV3{
+			MessageTTL: highloadV3MessageTTL,
+			MessageBuilder: func(context.Context, uint32) (uint32, int64, error) {
+				return 0, time.Now().Unix(), nil
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ext, err := w.BuildExternalMessageForMany(ctx, []*wallet.Message{msg})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build external message: %w", err)
+	}
+
+	extCell, err := tlb.ToCell(ext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize external message: %w", err)
+	}
+
+	outCell, err := tlb.ToCell(msg.InternalMessage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize internal message: %w", err)
+	}
+
+	block, err := net.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	acc, err := net.api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, w.WalletAddress())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	masterchain := w.WalletAddress().Workchain() == address.MasterchainID
+	fwd, gas, storage, err := networkPrices(ctx, net.api, block, masterchain)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := new(big.Int)
+	storageFee := new(big.Int)
+	if acc.IsActive && acc.State != nil {
+		balance = acc.State.Balance.Nano()
+		storageFee = storage.fee(&acc.State.StorageInfo, time.Now().Unix())
+	}
+
+	gasUsed := walletTransferGas[stored.WalletType]
+	importFee := fwd.fee(extCell)
+	computeFee := gas.fee(gasUsed)
+	forwardFee := fwd.fee(outCell)
+
+	// До action фазы баланс уменьшается на хранение, прием и газ
+	available := new(big.Int).Sub(balance, storageFee)
+	available.Sub(available, importFee)
+	available.Sub(available, computeFee)
+
+	amount := msg.InternalMessage.Amount.Nano()
+	spent := new(big.Int).Set(amount)
+	recipientAmount := new(big.Int).Sub(amount, forwardFee)
+	switch {
+	case msg.Mode&SendModeCarryAllBalance != 0:
+		amount = new(big.Int).Set(available)
+		spent = amount
+		recipientAmount = new(big.Int).Sub(amount, forwardFee)
+	case msg.Mode&SendFlagPayFeesSeparately != 0:
+		spent = new(big.Int).Add(amount, forwardFee)
+		recipientAmount = amount
+	}
+
+	resulting := new(big.Int).Sub(available, spent)
+
+	totalFee := new(big.Int).Add(importFee, storageFee)
+	totalFee.Add(totalFee, computeFee)
+	totalFee.Add(totalFee, forwardFee)
+
+	return &FeeEstimate{
+		Address:          w.WalletAddress().String(),
+		Recipient:        transfer.Recipient,
+		Mode:             msg.Mode,
+		Bounce:           msg.InternalMessage.Bounce,
+		Deploy:           ext.StateInit != nil,
+		Balance:          nanoToTON(balance),
+		Amount:           nanoToTON(amount),
+		RecipientAmount:  nanoToTON(recipientAmount),
+		ImportFee:        nanoToTON(importFee),
+		StorageFee:       nanoToTON(storageFee),
+		ComputeFee:       nanoToTON(computeFee),
+		ForwardFee:       nanoToTON(forwardFee),
+		TotalFee:         nanoToTON(totalFee),
+		ResultingBalance: nanoToTON(resulting),
+		Sufficient:       resulting.Sign() >= 0 && recipientAmount.Sign() >= 0,
+		GasUsed:          gasUsed,
+	}, nil
+}
+
+// networkPrices читает цены пересылки, газа и хранения для воркчейна кошелька
+func networkPrices(ctx context.Context, api ton.APIClientWrapped, block *ton.BlockIDExt, masterchain bool) (*msgForwardPrices, *gasPrices, *storagePrices, error) {
+	fwdParam, gasParam := int32(configForwardPricesBc), int32(configGasPricesBc)
+	if masterchain {
+		fwdParam, gasParam = configForwardPricesMc, configGasPricesMc
+	}
+
+	cfg, err := api.GetBlockchainConfig(ctx, block, configStoragePrices, gasParam, fwdParam)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get blockchain config: %w", err)
+	}
+
+	fwd, err := loadForwardPrices(cfg.Get(fwdParam))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("config param %d: %w", fwdParam, err)
+	}
+
+	gas, err := loadGasPrices(cfg.Get(gasParam))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("config param %d: %w", gasParam, err)
+	}
+
+	storage, err := loadStoragePrices(cfg.Get(configStoragePrices), masterchain, time.Now().Unix())
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("config param %d: %w", configStoragePrices, err)
+	}
+
+	return fwd, gas, storage, nil
+}
+
+// msg_prices#ea lump_price:uint64 bit_price:uint64 cell_price:uint64 ...
+func loadForwardPrices(c *cell.Cell) (*msgForwardPrices, error) {
+	s := c.BeginParse()
+	if tag, err := s.LoadUInt(8); err != nil || tag != 0xea {
+		return nil, fmt.Errorf("unexpected msg forward prices tag")
+	}
+
+	var p msgForwardPrices
+	var err error
+	if p.lumpPrice, err = s.LoadUInt(64); err != nil {
+		return nil, err
+	}
+	if p.bitPrice, err = s.LoadUInt(64); err != nil {
+		return nil, err
+	}
+	if p.cellPrice, err = s.LoadUInt(64); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// gas_flat_pfx#d1 flat_gas_limit:uint64 flat_gas_price:uint64 other:GasLimitsPrices
+// gas_prices#dd / gas_prices_ext#de gas_price:uint64 ...
+func loadGasPrices(c *cell.Cell) (*gasPrices, error) {
+	s := c.BeginParse()
+
+	var p gasPrices
+	tag, err := s.LoadUInt(8)
+	if err != nil {
+		return nil, err
+	}
+
+	if tag == 0xd1 {
+		if p.flatGasLimit, err = s.LoadUInt(64); err != nil {
+			return nil, err
+		}
+		if p.flatGasPrice, err = s.LoadUInt(64); err != nil {
+			return nil, err
+		}
+		if tag, err = s.LoadUInt(8); err != nil {
+			return nil, err
+		}
+	}
+
+	if tag != 0xdd && tag != 0xde {
+		return nil, fmt.Errorf("unexpected gas prices tag %x", tag)
+	}
+
+	if p.gasPrice, err = s.LoadUInt(64); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// _ (Hashmap 32 StoragePrices) = ConfigParam 18; берется последняя запись,
+// действующая на момент now
+// #cc utime_since:uint32 bit_price_ps:uint64 cell_price_ps:uint64 mc_bit_price_ps:uint64 mc_cell_price_ps:uint64
+func loadStoragePrices(c *cell.Cell, masterchain bool, now int64) (*storagePrices, error) {
+	entries, err := c.AsDict(32).LoadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var result *storagePrices
+	var since uint64
+	for _, kv := range entries {
+		s := kv.Value
+		if tag, err := s.LoadUInt(8); err != nil || tag != 0xcc {
+			return nil, fmt.Errorf("unexpected storage prices tag")
+		}
+
+		utime, err := s.LoadUInt(32)
+		if err != nil {
+			return nil, err
+		}
+		if int64(utime) > now || (result != nil && utime < since) {
+			continue
+		}
+
+		var prices [4]uint64
+		for i := range prices {
+			if prices[i], err = s.LoadUInt(64); err != nil {
+				return nil, err
+			}
+		}
+
+		result = &storagePrices{bitPrice: prices[0], cellPrice: prices[1]}
+		if masterchain {
+			result = &storagePrices{bitPrice: prices[2], cellPrice: prices[3]}
+		}
+		since = utime
+	}
+
+	if result == nil {
+		return nil, fmt.Errorf("no storage prices in effect")
+	}
+
+	return result, nil
+}
+
+// fee - lump_price + ceil((bit_price * bits + cell_price * cells) / 2^16),
+// где биты и ячейки считаются без корневой ячейки сообщения
+func (p *msgForwardPrices) fee(msg *cell.Cell) *big.Int {
+	bits, cells := cellStats(msg)
+
+	v := new(big.Int).Mul(new(big.Int).SetUint64(p.bitPrice), big.NewInt(int64(bits)))
+	v.Add(v, new(big.Int).Mul(new(big.Int).SetUint64(p.cellPrice), big.NewInt(int64(cells))))
+
+	return v.Add(divCeil16(v), new(big.Int).SetUint64(p.lumpPrice))
+}
+
+func (p *gasPrices) fee(gasUsed int64) *big.Int {
+	gas := uint64(gasUsed)
+	if gas <= p.flatGasLimit {
+		return new(big.Int).SetUint64(p.flatGasPrice)
+	}
+
+	v := new(big.Int).Mul(new(big.Int).SetUint64(gas-p.flatGasLimit), new(big.Int).SetUint64(p.gasPrice))
+	return v.Add(divCeil16(v), new(big.Int).SetUint64(p.flatGasPrice))
+}
+
+// fee - плата за хранение с last_paid до now и накопленный долг
+func (p *storagePrices) fee(info *tlb.StorageInfo, now int64) *big.Int {
+	v := new(big.Int)
+	if period := now - int64(info.LastPaid); period > 0 && info.StorageUsed.BitsUsed != nil && info.StorageUsed.CellsUsed != nil {
+		v.Mul(info.StorageUsed.BitsUsed, new(big.Int).SetUint64(p.bitPrice))
+		v.Add(v, new(big.Int).Mul(info.StorageUsed.CellsUsed, new(big.Int).SetUint64(p.cellPrice)))
+		v.Mul(v, big.NewInt(period))
+		v = divCeil16(v)
+	}
+
+	if info.DuePayment != nil {
+		v.Add(v, info.DuePayment.Nano())
+	}
+
+	return v
+}
+
+// cellStats считает уникальные ячейки и биты дерева без корня
+func cellStats(root *cell.Cell) (bits, cells uint64) {
+	seen := map[string]bool{}
+
+	var walk func(c *cell.Cell)
+	walk = func(c *cell.Cell) {
+		for i := 0; i < int(c.RefsNum()); i++ {
+			ref := c.MustPeekRef(i)
+			key := string(ref.Hash())
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			bits += uint64(ref.BitsSize())
+			cells++
+			walk(ref)
+		}
+	}
+	walk(root)
+
+	return bits, cells
+}
+
+func divCeil16(v *big.Int) *big.Int {
+	v = new(big.Int).Add(v, big.NewInt(1<<16-1))
+	return v.Rsh(v, 16)
+}
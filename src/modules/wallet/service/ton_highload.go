@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// Статусы model.HighloadQuery
+const (
+	HighloadQueryPending   = "pending"
+	HighloadQueryProcessed = "processed"
+	HighloadQueryExpired   = "expired"
+)
+
+const (
+	// highloadQueryIDLimit - query_id highload v3 занимает 23 бита
+	highloadQueryIDLimit = 1 << 23
+
+	// highloadClockSkew - на сколько секунд created_at сдвигается в прошлое,
+	// чтобы сообщение не отклонялось из-за отставания времени блокчейна
+	highloadClockSkew = 30
+
+	// highloadQueryAttempts - сколько query_id пробуется, если выданный
+	// счетчиком уже обработан контрактом (кошелек использовался вне сервиса)
+	highloadQueryAttempts = 8
+)
+
+// highloadQueryStore выдает query_id и хранит отправленные сообщения highload v3.
+// query_id берется из счетчика wallets.highload_query_seq, который увеличивается
+// атомарно, поэтому параллельные отправки не получают одинаковых query_id.
+// Контракт помнит query_id не дольше двух MessageTTL, так что повтор значения
+// после 2^23 отправок безопасен.
+type highloadQueryStore struct {
+	db *bun.DB
+}
+
+func (q *highloadQueryStore) next(ctx context.Context, walletID int64) (uint32, error) {
+	var seq int64
+	err := q.db.NewUpdate().
+		Model((*model.Wallet)(nil)).
+		Set("highload_query_seq = highload_query_seq + 1").
+		Where("id = ?", walletID).
+		Returning("highload_query_seq").
+		Scan(ctx, &seq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate highload query id: %w", err)
+	}
+
+	return uint32((seq - 1) % highloadQueryIDLimit), nil
+}
+
+func (q *highloadQueryStore) insert(ctx context.Context, query *model.HighloadQuery) error {
+	if _, err := q.db.NewInsert().Model(query).Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save highload query: %w", err)
+	}
+	return nil
+}
+
+func (q *highloadQueryStore) update(ctx context.Context, query *model.HighloadQuery) error {
+	query.UpdatedAt = time.Now()
+
+	_, err := q.db.NewUpdate().
+		Model(query).
+		Column("status", "tx_hash", "error", "updated_at").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update highload query: %w", err)
+	}
+	return nil
+}
+
+// prepareHighload выделяет query_id, подписывает внешнее сообщение и
+// сохраняет его в статусе pending
+func (s *TONService) prepareHighload(ctx context.Context, stored *model.Wallet, seed *Seed, messages []*wallet.Message) (*wallet.Wallet, *tonNetwork, *model.HighloadQuery, *tlb.ExternalMessage, error) {
+	w, net, err := s.openWallet(stored, seed)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	query, err := s.allocateHighloadQuery(ctx, stored, net, w.WalletAddress())
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	// MessageBuilder отдает уже выделенный query_id
+	hw, err := deriveWallet(net, stored, seed, wallet.ConfigHighloadV3{
+		MessageTTL: highloadV3MessageTTL,
+		MessageBuilder: func(context.Context, uint32) (uint32, int64, error) {
+			return uint32(query.QueryID), query.MsgCreatedAt, nil
+		},
+	})
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	ext, err := hw.BuildExternalMessageForMany(ctx, messages)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to build highload message: %w", err)
+	}
+
+	extCell, err := tlb.ToCell(ext)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to serialize highload message: %w", err)
+	}
+
+	query.MsgHash = hex.EncodeToString(ext.Body.Hash())
+	query.Boc = base64.StdEncoding.EncodeToString(extCell.ToBOC())
+	query.Messages = len(messages)
+	query.Status = HighloadQueryPending
+
+	if err := s.highloadQueries.insert(ctx, query); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	return hw, net, query, ext, nil
+}
+
+// allocateHighloadQuery выделяет query_id, не обработанный контрактом
+func (s *TONService) allocateHighloadQuery(ctx context.Context, stored *model.Wallet, net *tonNetwork, addr *address.Address) (*model.HighloadQuery, error) {
+	for range highloadQueryAttempts {
+		queryID, err := s.highloadQueries.next(ctx, stored.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		processed, err := highloadProcessed(ctx, net.api, addr, queryID)
+		if err != nil {
+			return nil, err
+		}
+		if processed {
+			continue
+		}
+
+		createdAt := time.Now().Unix() - highloadClockSkew
+		return &model.HighloadQuery{
+			WalletID:     stored.ID,
+			QueryID:      int64(queryID),
+			MsgCreatedAt: createdAt,
+			ExpiresAt:    time.Unix(createdAt+highloadV3MessageTTL, 0),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no free highload query id after %d attempts", highloadQueryAttempts)
+}
+
+// broadcastHighload отправляет сохраненное сообщение и фиксирует результат.
+// При ошибке запись остается pending: сообщение могло быть принято.
+func (s *TONService) broadcastHighload(ctx context.Context, net *tonNetwork, query *model.HighloadQuery, ext *tlb.ExternalMessage) (*tlb.Transaction, error) {
+	tx, _, _, err := net.api.SendExternalMessageWaitTransaction(ctx, ext)
+	if err != nil {
+		query.Error = err.Error()
+		if uerr := s.highloadQueries.update(ctx, query); uerr != nil {
+			err = errors.Join(err, uerr)
+		}
+		return nil, fmt.Errorf("failed to send highload query %d: %w", query.QueryID, err)
+	}
+
+	query.Status = HighloadQueryProcessed
+	query.TxHash = base64.StdEncoding.EncodeToString(tx.Hash)
+	query.Error = ""
+	if err := s.highloadQueries.update(ctx, query); err != nil {
+		// Транзакция уже в блокчейне, ошибку учета не возвращаем
+		log.Printf("Highload query %d processed in tx %s: %v", query.ID, query.TxHash, err)
+	}
+
+	return tx, nil
+}
+
+// RetryHighloadQuery повторно отправляет сохраненное сообщение, если контракт
+// его не обработал и время жизни не истекло. Повтор того же сообщения
+// безопасен: контракт отклоняет уже обработанный query_id.
+func (s *TONService) RetryHighloadQuery(ctx context.Context, stored *model.Wallet, query *model.HighloadQuery) error {
+	if query.Status != HighloadQueryPending {
+		return nil
+	}
+
+	addr, net, err := s.walletAddress(stored, nil)
+	if err != nil {
+		return err
+	}
+
+	done, err := s.resolveHighloadQuery(ctx, net, addr, query)
+	if err != nil || done {
+		return err
+	}
+
+	boc, err := base64.StdEncoding.DecodeString(query.Boc)
+	if err != nil {
+		return fmt.Errorf("invalid stored highload message: %w", err)
+	}
+
+	extCell, err := cell.FromBOC(boc)
+	if err != nil {
+		return fmt.Errorf("invalid stored highload message: %w", err)
+	}
+
+	var ext tlb.ExternalMessage
+	if err := tlb.LoadFromCell(&ext, extCell.BeginParse()); err != nil {
+		return fmt.Errorf("invalid stored highload message: %w", err)
+	}
+
+	_, err = s.broadcastHighload(ctx, net, query, &ext)
+	return err
+}
+
+// RefreshHighloadQuery уточняет статус pending сообщения по контракту
+func (s *TONService) RefreshHighloadQuery(ctx context.Context, stored *model.Wallet, query *model.HighloadQuery) error {
+	if query.Status != HighloadQueryPending {
+		return nil
+	}
+
+	addr, net, err := s.walletAddress(stored, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.resolveHighloadQuery(ctx, net, addr, query)
+	return err
+}
+
+// resolveHighloadQuery переводит запись в processed, если контракт обработал
+// query_id, или в expired, если сообщение уже не может быть принято.
+// Возвращает false, если сообщение еще может быть обработано.
+func (s *TONService) resolveHighloadQuery(ctx context.Context, net *tonNetwork, addr *address.Address, query *model.HighloadQuery) (bool, error) {
+	// Время проверяем до get-метода: после истечения сообщение уже не примут,
+	// и ответ processed? окончателен
+	expired := time.Now().After(query.ExpiresAt.Add(highloadClockSkew * time.Second))
+
+	processed, err := highloadProcessed(ctx, net.api, addr, uint32(query.QueryID))
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case processed:
+		query.Status = HighloadQueryProcessed
+		query.Error = ""
+	case expired:
+		query.Status = HighloadQueryExpired
+	default:
+		return false, nil
+	}
+
+	return true, s.highloadQueries.update(ctx, query)
+}
+
+// highloadProcessed вызывает get-метод processed? контракта highload v3.
+// Неразвернутый контракт еще ничего не обработал.
+func highloadProcessed(ctx context.Context, api ton.APIClientWrapped, addr *address.Address, queryID uint32) (bool, error) {
+	block, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	res, err := api.WaitForBlock(block.SeqNo).RunGetMethod(ctx, block, addr, "processed?", uint64(queryID), 0)
+	if err != nil {
+		var execErr ton.ContractExecError
+		if errors.As(err, &execErr) && execErr.Code == ton.ErrCodeContractNotInitialized {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check highload query: %w", err)
+	}
+
+	processed, err := res.Int(0)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse processed? result: %w", err)
+	}
+
+	return processed.Sign() != 0, nil
+}
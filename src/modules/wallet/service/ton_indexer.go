@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+
+	"wallet_test/src/modules/wallet/model"
+)
+
+// indexPageLimit - сколько транзакций загружается за один запрос индексатора:
+// новых, пропущенных за время простоя и старой истории
+const indexPageLimit = 1000
+
+// IndexedPage - транзакции, загруженные индексатором, от новых к старым
+type IndexedPage struct {
+	Transactions []*TransactionInfo
+	// PrevLt/PrevHash - транзакция перед самой старой из загруженных,
+	// с нее продолжается догрузка истории (PrevLt == 0 - история загружена полностью)
+	PrevLt   uint64
+	PrevHash []byte
+}
+
+// IndexTransactions возвращает до indexPageLimit транзакций кошелька с
+// lt > afterLt, начиная с транзакции lt/hash (при lt == 0 - с последней
+// транзакции аккаунта). PrevLt > afterLt значит, что до afterLt страница
+// не дошла и остаток загружается следующей страницей с PrevLt/PrevHash.
+func (s *TONService) IndexTransactions(ctx context.Context, stored *model.Wallet, lt uint64, hash []byte, afterLt uint64) (*IndexedPage, error) {
+	return s.indexPage(ctx, stored, lt, hash, afterLt, indexPageLimit)
+}
+
+// IndexHistory возвращает до indexPageLimit транзакций кошелька,
+// начиная с транзакции lt/hash и дальше в прошлое
+func (s *TONService) IndexHistory(ctx context.Context, stored *model.Wallet, lt uint64, hash []byte) (*IndexedPage, error) {
+	return s.indexPage(ctx, stored, lt, hash, 0, indexPageLimit)
+}
+
+func (s *TONService) indexPage(ctx context.Context, stored *model.Wallet, lt uint64, hash []byte, afterLt uint64, limit int) (*IndexedPage, error) {
+	addr, net, err := s.walletAddress(stored, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	txList, err := scanTransactionsAfter(ctx, net.api, addr, lt, hash, afterLt, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &IndexedPage{Transactions: make([]*TransactionInfo, 0, len(txList))}
+	for _, tx := range txList {
+		page.Transactions = append(page.Transactions, transactionInfo(addr, tx))
+	}
+
+	if err := s.verifyJettonNotifications(ctx, net, addr, page.Transactions); err != nil {
+		return nil, err
+	}
+
+	if len(txList) > 0 {
+		oldest := txList[len(txList)-1]
+		page.PrevLt, page.PrevHash = oldest.PrevTxLT, oldest.PrevTxHash
+	}
+
+	return page, nil
+}
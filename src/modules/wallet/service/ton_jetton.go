@@ -0,0 +1,373 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/jetton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// Опкоды TEP-74
+const (
+	jettonOpTransfer             = 0x0f8a7ea5
+	jettonOpTransferNotification = 0x7362d09c
+)
+
+// jettonTransferGas - TON сверх forward_amount на газ jetton-кошельков
+const jettonTransferGas = "0.05"
+
+type JettonInfo struct {
+	Master      string           `json:"master"`
+	TotalSupply string           `json:"total_supply"`
+	Mintable    bool             `json:"mintable"`
+	Admin       string           `json:"admin,omitempty"`
+	Decimals    int              `json:"decimals"`
+	Metadata    *ContentMetadata `json:"metadata"`
+}
+
+type JettonBalance struct {
+	Jetton       *JettonInfo `json:"jetton"`
+	JettonWallet string      `json:"jetton_wallet"`
+	Balance      string      `json:"balance"`     // в минимальных единицах
+	BalanceUnits string      `json:"balance_fmt"` // с учетом decimals
+}
+
+type JettonTransfer struct {
+	Master              string
+	Recipient           string
+	Amount              string // в единицах жетона с учетом decimals, например "10.5"
+	ForwardAmount       string // TON для уведомления получателя
+	ForwardComment      string
+	ResponseDestination string // куда вернуть излишек TON, по умолчанию сам кошелек
+}
+
+// JettonTransferResult - отправка transfer на jetton-кошелек. Send - pending
+// запись, итоговый статус которой обновляет TrackPendingSends.
+type JettonTransferResult struct {
+	Send         *model.Transaction `json:"send"`
+	Master       string             `json:"master"`
+	JettonWallet string             `json:"jetton_wallet"`
+	Amount       string             `json:"amount"` // в единицах жетона
+	Recipient    string             `json:"recipient"`
+	Comment      string             `json:"comment,omitempty"`
+}
+
+// JettonTransferInfo - распознанный в истории перевод жетонов.
+// Хранится в transactions.jetton, поэтому объявлен в model.
+type JettonTransferInfo = model.JettonTransfer
+
+func (s *TONService) GetJettonInfo(ctx context.Context, master, network string) (*JettonInfo, error) {
+	net, err := s.network(network)
+	if err != nil {
+		return nil, err
+	}
+
+	masterAddr, err := address.ParseAddr(master)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+	}
+
+	return s.jettonInfo(ctx, net, masterAddr)
+}
+
+func (s *TONService) jettonInfo(ctx context.Context, net *tonNetwork, masterAddr *address.Address) (*JettonInfo, error) {
+	data, err := jetton.NewJettonMasterClient(net.api, masterAddr).GetJettonData(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jetton data: %w", err)
+	}
+
+	meta, err := resolveContent(ctx, data.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve jetton metadata: %w", err)
+	}
+
+	info := &JettonInfo{
+		Master:      masterAddr.String(),
+		TotalSupply: data.TotalSupply.String(),
+		Mintable:    data.Mintable,
+		Decimals:    meta.decimals(),
+		Metadata:    meta,
+	}
+	if data.AdminAddr != nil && !data.AdminAddr.IsAddrNone() {
+		info.Admin = data.AdminAddr.String()
+	}
+
+	return info, nil
+}
+
+// GetJettonBalances возвращает балансы кошелька по указанным jetton master контрактам
+func (s *TONService) GetJettonBalances(ctx context.Context, stored *model.Wallet, seed *Seed, masters []string) ([]*JettonBalance, error) {
+	owner, net, err := s.walletAddress(stored, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]*JettonBalance, 0, len(masters))
+	for _, master := range masters {
+		masterAddr, err := address.ParseAddr(master)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidAddress, master, err)
+		}
+
+		info, err := s.jettonInfo(ctx, net, masterAddr)
+		if err != nil {
+			return nil, err
+		}
+
+		jettonWallet, err := jetton.NewJettonMasterClient(net.api, masterAddr).GetJettonWallet(ctx, owner)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve jetton wallet: %w", err)
+		}
+
+		balance, err := jettonWallet.GetBalance(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get jetton balance: %w", err)
+		}
+
+		units, err := tlb.FromNano(balance, info.Decimals)
+		if err != nil {
+			return nil, fmt.Errorf("failed to format jetton balance: %w", err)
+		}
+
+		balances = append(balances, &JettonBalance{
+			Jetton:       info,
+			JettonWallet: jettonWallet.Address().String(),
+			Balance:      balance.String(),
+			BalanceUnits: units.String(),
+		})
+	}
+
+	return balances, nil
+}
+
+// JettonTransferMessage собирает transfer (TEP-74) на jetton-кошелек
+// отправителя. Сообщение отправляет WalletService, записывая pending отправку в Send.
+func (s *TONService) JettonTransferMessage(ctx context.Context, stored *model.Wallet, seed *Seed, transfer *JettonTransfer) (*wallet.Message, *JettonTransferResult, error) {
+	owner, net, err := s.walletAddress(stored, seed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	masterAddr, err := address.ParseAddr(transfer.Master)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: master: %v", ErrInvalidAddress, err)
+	}
+
+	recipient, err := address.ParseAddr(transfer.Recipient)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: recipient: %v", ErrInvalidAddress, err)
+	}
+
+	responseTo := owner
+	if transfer.ResponseDestination != "" {
+		responseTo, err = address.ParseAddr(transfer.ResponseDestination)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: response destination: %v", ErrInvalidAddress, err)
+		}
+	}
+
+	info, err := s.jettonInfo(ctx, net, masterAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	amount, err := tlb.FromDecimal(transfer.Amount, info.Decimals)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid amount: %w", err)
+	}
+
+	forwardAmount := tlb.ZeroCoins
+	if transfer.ForwardAmount != "" {
+		forwardAmount, err = tlb.FromTON(transfer.ForwardAmount)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid forward amount: %w", err)
+		}
+	}
+
+	var forwardPayload *cell.Cell
+	if transfer.ForwardComment != "" {
+		forwardPayload, err = wallet.CreateCommentCell(transfer.ForwardComment)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create forward comment: %w", err)
+		}
+	}
+
+	jettonWallet, err := jetton.NewJettonMasterClient(net.api, masterAddr).GetJettonWallet(ctx, owner)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve jetton wallet: %w", err)
+	}
+
+	body, err := jettonWallet.BuildTransferPayloadV2(recipient, responseTo, amount, forwardAmount, forwardPayload, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build jetton transfer: %w", err)
+	}
+
+	// На jetton-кошелек отправляем forward_amount и запас на газ
+	attached := new(big.Int).Add(forwardAmount.Nano(), tlb.MustFromTON(jettonTransferGas).Nano())
+
+	msg := &wallet.Message{
+		Mode: wallet.PayGasSeparately + wallet.IgnoreErrors,
+		InternalMessage: &tlb.InternalMessage{
+			IHRDisabled: true,
+			Bounce:      true,
+			DstAddr:     jettonWallet.Address(),
+			Amount:      tlb.FromNanoTON(attached),
+			Body:        body,
+		},
+	}
+
+	return msg, &JettonTransferResult{
+		Master:       masterAddr.String(),
+		JettonWallet: jettonWallet.Address().String(),
+		Amount:       transfer.Amount,
+		Recipient:    transfer.Recipient,
+		Comment:      transfer.ForwardComment,
+	}, nil
+}
+
+// jettonNotification - входящий transfer_notification. Попадает в историю
+// только после проверки отправителя в verifyJettonNotifications.
+type jettonNotification struct {
+	msg      *TxMessageInfo
+	transfer *JettonTransferInfo
+	sender   *address.Address
+}
+
+// verifyJettonNotifications принимает transfer_notification, только если его
+// отправитель - jetton-кошелек owner у master контракта из get_wallet_data
+// отправителя. Такой опкод может отправить любой контракт, поэтому
+// непроверенные уведомления в историю и события не попадают. Ошибка сети
+// возвращается, чтобы транзакции не сохранились без проверки.
+func (s *TONService) verifyJettonNotifications(ctx context.Context, net *tonNetwork, owner *address.Address, transactions []*TransactionInfo) error {
+	masters := map[string]string{} // jetton-кошелек -> master, "" - не jetton-кошелек owner
+	for _, info := range transactions {
+		n := info.notification
+		if n == nil {
+			continue
+		}
+
+		raw := rawAddress(n.sender)
+		master, ok := masters[raw]
+		if !ok {
+			var err error
+			master, err = s.jettonWalletMaster(ctx, net, owner, n.sender)
+			if err != nil {
+				return err
+			}
+			masters[raw] = master
+		}
+		if master == "" {
+			continue
+		}
+
+		n.transfer.Master = master
+		n.msg.Jetton = n.transfer
+		if info.Jetton == nil {
+			info.Jetton = n.transfer
+		}
+	}
+
+	return nil
+}
+
+// jettonWalletMaster возвращает master контракт jetton-кошелька jettonWallet,
+// если master выдает этот адрес для owner. Пустая строка - контракт не
+// является jetton-кошельком owner.
+func (s *TONService) jettonWalletMaster(ctx context.Context, net *tonNetwork, owner, jettonWallet *address.Address) (string, error) {
+	block, err := net.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	var execErr ton.ContractExecError
+
+	res, err := net.api.WaitForBlock(block.SeqNo).RunGetMethod(ctx, block, jettonWallet, "get_wallet_data")
+	if errors.As(err, &execErr) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to run get_wallet_data: %w", err)
+	}
+
+	slice, err := res.Slice(2)
+	if err != nil {
+		return "", nil
+	}
+	masterAddr, err := slice.LoadAddr()
+	if err != nil {
+		return "", nil
+	}
+
+	expected, err := jetton.NewJettonMasterClient(net.api, masterAddr).GetJettonWalletAtBlock(ctx, owner, block)
+	if errors.As(err, &execErr) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve jetton wallet: %w", err)
+	}
+
+	if !expected.Address().Equals(jettonWallet) {
+		return "", nil
+	}
+
+	return masterAddr.String(), nil
+}
+
+// parseJettonNotification разбирает входящий transfer_notification.
+// Результат принимается только после verifyJettonNotifications.
+func parseJettonNotification(msg *tlb.InternalMessage) *JettonTransferInfo {
+	if msg.Body == nil || bodyOpcode(msg.Body) != jettonOpTransferNotification {
+		return nil
+	}
+
+	var notification jetton.TransferNotification
+	if err := tlb.LoadFromCell(&notification, msg.Body.BeginParse()); err != nil {
+		return nil
+	}
+
+	info := &JettonTransferInfo{
+		Direction:    "in",
+		QueryID:      notification.QueryID,
+		Amount:       notification.Amount.Nano().String(),
+		JettonWallet: msg.SrcAddr.String(),
+		Comment:      commentFromBody(notification.ForwardPayload),
+	}
+	if notification.Sender != nil {
+		info.Counterparty = notification.Sender.String()
+	}
+
+	return info
+}
+
+// parseJettonTransfer распознает исходящий transfer на наш jetton-кошелек
+func parseJettonTransfer(msg *tlb.InternalMessage) *JettonTransferInfo {
+	if msg.Body == nil || bodyOpcode(msg.Body) != jettonOpTransfer {
+		return nil
+	}
+
+	var transfer jetton.TransferPayload
+	if err := tlb.LoadFromCell(&transfer, msg.Body.BeginParse()); err != nil {
+		return nil
+	}
+
+	info := &JettonTransferInfo{
+		Direction:    "out",
+		QueryID:      transfer.QueryID,
+		Amount:       transfer.Amount.Nano().String(),
+		JettonWallet: msg.DstAddr.String(),
+		Comment:      commentFromBody(transfer.ForwardPayload),
+	}
+	if transfer.Destination != nil {
+		info.Counterparty = transfer.Destination.String()
+	}
+
+	return info
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+// txLookupScanLimit - сколько последних транзакций аккаунта просматривается,
+// если транзакцию нельзя прочитать напрямую по lt и хешу
+const txLookupScanLimit = 100
+
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// TxFees - комиссии транзакции по фазам, в TON
+type TxFees struct {
+	Total   string `json:"total"`   // total_fees транзакции
+	Import  string `json:"import"`  // прием внешнего сообщения
+	Storage string `json:"storage"` // storage фаза
+	Compute string `json:"compute"` // газ compute фазы
+	Action  string `json:"action"`  // action фаза
+	Forward string `json:"forward"` // пересылка исходящих сообщений (total_fwd_fees)
+	GasUsed int64  `json:"gas_used"`
+}
+
+// ChainTransaction - транзакция, прочитанная из блокчейна
+type ChainTransaction struct {
+	Info *TransactionInfo
+	Fees *TxFees
+}
+
+// LookupTransaction ищет транзакцию аккаунта по хешу транзакции или хешу
+// внешнего сообщения (хеш тела или нормализованный, TEP-467). Транзакция с
+// известным lt читается напрямую, иначе просматриваются последние
+// txLookupScanLimit транзакций аккаунта.
+func (s *TONService) LookupTransaction(ctx context.Context, network string, addr *address.Address, lt uint64, hash []byte) (*ChainTransaction, error) {
+	net, err := s.network(network)
+	if err != nil {
+		return nil, err
+	}
+
+	// Прямое чтение возможно только по паре lt и хеш транзакции
+	if lt != 0 {
+		list, err := net.api.ListTransactions(ctx, addr, 1, lt, hash)
+		if err == nil && len(list) == 1 && bytes.Equal(list[0].Hash, hash) {
+			return s.chainTransaction(ctx, net, addr, list[0])
+		}
+	}
+
+	txList, err := scanTransactions(ctx, net.api, addr, 0, nil, txLookupScanLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tx := range txList {
+		if transactionMatches(tx, hash) {
+			return s.chainTransaction(ctx, net, addr, tx)
+		}
+	}
+
+	return nil, ErrTransactionNotFound
+}
+
+func (s *TONService) chainTransaction(ctx context.Context, net *tonNetwork, addr *address.Address, tx *tlb.Transaction) (*ChainTransaction, error) {
+	info := transactionInfo(addr, tx)
+	if err := s.verifyJettonNotifications(ctx, net, addr, []*TransactionInfo{info}); err != nil {
+		return nil, err
+	}
+
+	return &ChainTransaction{
+		Info: info,
+		Fees: txFees(tx),
+	}, nil
+}
+
+// transactionMatches проверяет хеш транзакции и хеши ее внешнего сообщения
+func transactionMatches(tx *tlb.Transaction, hash []byte) bool {
+	if bytes.Equal(tx.Hash, hash) {
+		return true
+	}
+
+	if tx.IO.In == nil || tx.IO.In.MsgType != tlb.MsgTypeExternalIn {
+		return false
+	}
+
+	ext := tx.IO.In.AsExternalIn()
+	return bytes.Equal(ext.Body.Hash(), hash) || bytes.Equal(normalizedMessageHash(ext), hash)
+}
+
+// txFees разбирает комиссии обычной транзакции по фазам. У служебных
+// транзакций заполняется только общая сумма.
+func txFees(tx *tlb.Transaction) *TxFees {
+	fees := &TxFees{
+		Total:   txFee(tx),
+		Import:  "0",
+		Storage: "0",
+		Compute: "0",
+		Action:  "0",
+		Forward: "0",
+	}
+
+	if tx.IO.In != nil && tx.IO.In.MsgType == tlb.MsgTypeExternalIn {
+		fees.Import = nanoToTON(tx.IO.In.AsExternalIn().ImportFee.Nano())
+	}
+
+	desc, ok := tx.Description.Description.(tlb.TransactionDescriptionOrdinary)
+	if !ok {
+		return fees
+	}
+
+	if desc.StoragePhase != nil {
+		fees.Storage = nanoToTON(desc.StoragePhase.StorageFeesCollected.Nano())
+	}
+	if phase, ok := desc.ComputePhase.Phase.(tlb.ComputePhaseVM); ok {
+		fees.Compute = nanoToTON(phase.GasFees.Nano())
+		if phase.Details.GasUsed != nil {
+			fees.GasUsed = phase.Details.GasUsed.Int64()
+		}
+	}
+	if desc.ActionPhase != nil {
+		if desc.ActionPhase.TotalActionFees != nil {
+			fees.Action = nanoToTON(desc.ActionPhase.TotalActionFees.Nano())
+		}
+		if desc.ActionPhase.TotalFwdFees != nil {
+			fees.Forward = nanoToTON(desc.ActionPhase.TotalFwdFees.Nano())
+		}
+	}
+
+	return fees
+}
@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/nft"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// Опкоды TEP-62
+const (
+	nftOpTransfer          = 0x5fcc3d14
+	nftOpOwnershipAssigned = 0x05138d91
+)
+
+// nftTransferGas - TON сверх forward_amount на газ NFT item контракта
+const nftTransferGas = "0.05"
+
+var ErrNFTNotOwned = errors.New("nft item is not owned by wallet")
+
+type NFTCollectionInfo struct {
+	Address       string           `json:"address"`
+	Owner         string           `json:"owner,omitempty"`
+	NextItemIndex string           `json:"next_item_index"`
+	Metadata      *ContentMetadata `json:"metadata,omitempty"`
+	MetadataError string           `json:"metadata_error,omitempty"`
+}
+
+type NFTItemInfo struct {
+	Address       string             `json:"address"`
+	Index         string             `json:"index"`
+	Owner         string             `json:"owner"`
+	Collection    *NFTCollectionInfo `json:"collection,omitempty"` // nil у NFT без коллекции
+	Metadata      *ContentMetadata   `json:"metadata,omitempty"`
+	MetadataError string             `json:"metadata_error,omitempty"` // offchain метаданные недоступны
+
+	ownerAddr *address.Address
+}
+
+type NFTTransfer struct {
+	Item                string
+	NewOwner            string
+	QueryID             uint64 // 0 - сгенерировать
+	ForwardAmount       string // TON для ownership_assigned новому владельцу
+	ForwardComment      string
+	ResponseDestination string // куда вернуть излишек TON, по умолчанию сам кошелек
+}
+
+// NFTTransferResult - отправка transfer на NFT item. Send - pending запись,
+// итоговый статус которой обновляет TrackPendingSends.
+type NFTTransferResult struct {
+	Send     *model.Transaction `json:"send"`
+	Item     string             `json:"item"`
+	NewOwner string             `json:"new_owner"`
+	QueryID  uint64             `json:"query_id"`
+	Amount   string             `json:"amount"` // TON, отправленные на item
+	Comment  string             `json:"comment,omitempty"`
+}
+
+// NFTList - NFT кошелька, найденные ListNFTs
+type NFTList struct {
+	Items           []*NFTItemInfo
+	Errors          []*NFTItemError // кандидаты, которые не удалось проверить
+	Scanned         int             // сколько транзакций просмотрено
+	HistoryComplete bool            // просмотрена вся история кошелька
+}
+
+// NFTItemError - кандидат, владение которым не удалось проверить: адрес
+// не является NFT item (поддельное уведомление) или get_nft_data недоступен
+type NFTItemError struct {
+	Item  string `json:"item"`
+	Error string `json:"error"`
+}
+
+// ListNFTs возвращает NFT, которыми владеет кошелек. Кандидаты берутся из последних
+// scanLimit транзакций (ownership_assigned и исходящие transfer) и из items;
+// владение каждым проверяется через get_nft_data. NFT, полученные раньше
+// просмотренных транзакций или без forward_amount (без уведомления), находятся
+// только через items: HistoryComplete == false означает, что история
+// просмотрена не полностью.
+func (s *TONService) ListNFTs(ctx context.Context, stored *model.Wallet, seed *Seed, scanLimit int, items []string) (*NFTList, error) {
+	owner, net, err := s.walletAddress(stored, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*address.Address
+	seen := map[string]bool{}
+	addCandidate := func(addr *address.Address) {
+		key := rawAddress(addr)
+		if !seen[key] {
+			seen[key] = true
+			candidates = append(candidates, addr)
+		}
+	}
+
+	for _, item := range items {
+		addr, err := address.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidAddress, item, err)
+		}
+		addCandidate(addr)
+	}
+
+	txList, err := scanTransactions(ctx, net.api, owner, 0, nil, scanLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tx := range txList {
+		if tx.IO.In != nil && tx.IO.In.MsgType == tlb.MsgTypeInternal {
+			if msg := tx.IO.In.AsInternal(); msg.Body != nil && bodyOpcode(msg.Body) == nftOpOwnershipAssigned {
+				addCandidate(msg.SrcAddr)
+			}
+		}
+
+		if tx.IO.Out == nil {
+			continue
+		}
+		list, err := tx.IO.Out.ToSlice()
+		if err != nil {
+			continue
+		}
+		for _, out := range list {
+			if out.MsgType != tlb.MsgTypeInternal {
+				continue
+			}
+			if msg := out.AsInternal(); msg.Body != nil && bodyOpcode(msg.Body) == nftOpTransfer {
+				addCandidate(msg.DstAddr)
+			}
+		}
+	}
+
+	block, err := net.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	result := &NFTList{
+		Items:           make([]*NFTItemInfo, 0, len(candidates)),
+		Scanned:         len(txList),
+		HistoryComplete: len(txList) < scanLimit || txList[len(txList)-1].PrevTxLT == 0,
+	}
+
+	collections := map[string]*NFTCollectionInfo{}
+	for _, addr := range candidates {
+		item, err := s.nftItem(ctx, net, block, addr, collections)
+		if err != nil {
+			result.Errors = append(result.Errors, &NFTItemError{Item: addr.String(), Error: err.Error()})
+			continue
+		}
+		if item.ownerAddr != nil && item.ownerAddr.Equals(owner) {
+			result.Items = append(result.Items, item)
+		}
+	}
+
+	return result, nil
+}
+
+// GetNFTItem возвращает NFT item кошелька с метаданными item и коллекции
+func (s *TONService) GetNFTItem(ctx context.Context, stored *model.Wallet, seed *Seed, item string) (*NFTItemInfo, error) {
+	owner, net, err := s.walletAddress(stored, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := address.ParseAddr(item)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+	}
+
+	block, err := net.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	info, err := s.nftItem(ctx, net, block, addr, map[string]*NFTCollectionInfo{})
+	if err != nil {
+		return nil, err
+	}
+
+	if info.ownerAddr == nil || !info.ownerAddr.Equals(owner) {
+		return nil, fmt.Errorf("%w: %s", ErrNFTNotOwned, info.Address)
+	}
+
+	return info, nil
+}
+
+// nftItem читает данные NFT item и коллекции; коллекции кэшируются в collections
+func (s *TONService) nftItem(ctx context.Context, net *tonNetwork, block *ton.BlockIDExt, addr *address.Address, collections map[string]*NFTCollectionInfo) (*NFTItemInfo, error) {
+	data, err := nft.NewItemClient(net.api, addr).GetNFTDataAtBlock(ctx, block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nft data: %w", err)
+	}
+
+	if !data.Initialized {
+		return nil, fmt.Errorf("nft item %s is not initialized", addr.String())
+	}
+
+	info := &NFTItemInfo{
+		Address: addr.Bounce(false).String(),
+		Index:   data.Index.String(),
+	}
+	if data.OwnerAddress != nil && !data.OwnerAddress.IsAddrNone() {
+		info.ownerAddr = data.OwnerAddress
+		info.Owner = data.OwnerAddress.Bounce(false).Testnet(false).String()
+	}
+
+	content := data.Content
+	if data.CollectionAddress != nil && !data.CollectionAddress.IsAddrNone() {
+		collectionClient := nft.NewCollectionClient(net.api, data.CollectionAddress)
+
+		key := rawAddress(data.CollectionAddress)
+		collection, ok := collections[key]
+		if !ok {
+			collection, err = s.nftCollection(ctx, collectionClient, data.CollectionAddress, block)
+			if err != nil {
+				return nil, err
+			}
+			collections[key] = collection
+		}
+		info.Collection = collection
+
+		// Полный контент item собирает коллекция из индивидуальной части
+		content, err = collectionClient.GetNFTContentAtBlock(ctx, data.Index, data.Content, block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get nft content: %w", err)
+		}
+	}
+
+	if content != nil {
+		info.Metadata, err = resolveContent(ctx, content)
+		if err != nil {
+			info.MetadataError = err.Error()
+		}
+	}
+
+	return info, nil
+}
+
+func (s *TONService) nftCollection(ctx context.Context, client *nft.CollectionClient, addr *address.Address, block *ton.BlockIDExt) (*NFTCollectionInfo, error) {
+	data, err := client.GetCollectionDataAtBlock(ctx, block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection data: %w", err)
+	}
+
+	info := &NFTCollectionInfo{
+		Address:       addr.String(),
+		NextItemIndex: data.NextItemIndex.String(),
+	}
+	if data.OwnerAddress != nil && !data.OwnerAddress.IsAddrNone() {
+		info.Owner = data.OwnerAddress.String()
+	}
+
+	info.Metadata, err = resolveContent(ctx, data.Content)
+	if err != nil {
+		info.MetadataError = err.Error()
+	}
+
+	return info, nil
+}
+
+// NFTTransferMessage собирает transfer (TEP-62) на NFT item, которым владеет
+// кошелек. Сообщение отправляет WalletService, записывая pending отправку в Send.
+func (s *TONService) NFTTransferMessage(ctx context.Context, stored *model.Wallet, seed *Seed, transfer *NFTTransfer) (*wallet.Message, *NFTTransferResult, error) {
+	item, err := s.GetNFTItem(ctx, stored, seed, transfer.Item)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	itemAddr, err := address.ParseAddr(item.Address)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+	}
+
+	newOwner, err := address.ParseAddr(transfer.NewOwner)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: new owner: %v", ErrInvalidAddress, err)
+	}
+
+	responseTo, err := address.ParseAddr(stored.Address)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid stored wallet address: %w", err)
+	}
+	if transfer.ResponseDestination != "" {
+		responseTo, err = address.ParseAddr(transfer.ResponseDestination)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: response destination: %v", ErrInvalidAddress, err)
+		}
+	}
+
+	forwardAmount := tlb.ZeroCoins
+	if transfer.ForwardAmount != "" {
+		forwardAmount, err = tlb.FromTON(transfer.ForwardAmount)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid forward amount: %w", err)
+		}
+	}
+
+	forwardPayload := cell.BeginCell().EndCell()
+	if transfer.ForwardComment != "" {
+		forwardPayload, err = wallet.CreateCommentCell(transfer.ForwardComment)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create forward comment: %w", err)
+		}
+	}
+
+	queryID := transfer.QueryID
+	if queryID == 0 {
+		queryID, err = randomQueryID()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	body, err := tlb.ToCell(nft.TransferPayload{
+		QueryID:             queryID,
+		NewOwner:            newOwner,
+		ResponseDestination: responseTo,
+		ForwardAmount:       forwardAmount,
+		ForwardPayload:      forwardPayload,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build nft transfer: %w", err)
+	}
+
+	// На item отправляем forward_amount и запас на газ
+	attached := new(big.Int).Add(forwardAmount.Nano(), tlb.MustFromTON(nftTransferGas).Nano())
+
+	msg := &wallet.Message{
+		Mode: wallet.PayGasSeparately + wallet.IgnoreErrors,
+		InternalMessage: &tlb.InternalMessage{
+			IHRDisabled: true,
+			Bounce:      true,
+			DstAddr:     itemAddr,
+			Amount:      tlb.FromNanoTON(attached),
+			Body:        body,
+		},
+	}
+
+	return msg, &NFTTransferResult{
+		Item:     item.Address,
+		NewOwner: transfer.NewOwner,
+		QueryID:  queryID,
+		Amount:   nanoToTON(attached),
+		Comment:  transfer.ForwardComment,
+	}, nil
+}
@@ -0,0 +1,546 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// OfflineTransferVersion - версия формата OfflineTransfer
+const OfflineTransferVersion = 1
+
+// Срок действия неподписанного перевода: за это время его нужно перенести
+// на офлайн машину, подписать и вернуть на сервер
+const (
+	DefaultOfflineTTL = time.Hour
+	MaxOfflineTTL     = 24 * time.Hour
+)
+
+var (
+	ErrInvalidPublicKey       = errors.New("invalid public key")
+	ErrInvalidOfflineTransfer = errors.New("invalid offline transfer")
+	ErrOfflineNotSupported    = errors.New("offline signing is supported only for V5R1Final, V4R2 and V3R2 wallets")
+)
+
+// OfflineTransfer - перевод холодного кошелька для подписи вне сервиса.
+// Сервер заполняет все поля, кроме подписанного сообщения; подписывающая
+// сторона проверяет их, собирает тело заново и добавляет Boc.
+type OfflineTransfer struct {
+	Version    int               `json:"version"`
+	WalletID   int64             `json:"wallet_id"`
+	Address    string            `json:"address"`
+	PublicKey  string            `json:"public_key"` // ed25519, hex
+	WalletType string            `json:"wallet_type"`
+	Network    string            `json:"network"`
+	Subwallet  uint32            `json:"subwallet"`   // subwallet кошелька, входит в адрес
+	Seqno      uint32            `json:"seqno"`       // seqno кошелька на момент подготовки с учетом pending отправок
+	ValidUntil int64             `json:"valid_until"` // unix время, до которого сообщение принимается
+	Deploy     bool              `json:"deploy"`      // кошелек не развернут: сообщение несет state-init
+	Messages   []*OfflineMessage `json:"messages"`
+	Payload    string            `json:"payload"` // подписываемая часть тела, base64 BOC
+
+	// Заполняются при подписи
+	Boc     string `json:"boc,omitempty"`      // подписанное внешнее сообщение, base64 BOC
+	MsgHash string `json:"msg_hash,omitempty"` // хеш тела (hex), по нему отслеживается отправка
+	Hash    string `json:"hash,omitempty"`     // нормализованный хеш сообщения (hex), TEP-467
+}
+
+// OfflineMessage - внутреннее сообщение перевода и его описание для проверки.
+// Тело описывается либо текстовым комментарием, либо Payload целиком.
+type OfflineMessage struct {
+	Mode      uint8  `json:"mode"`
+	Recipient string `json:"recipient"`
+	Amount    string `json:"amount"` // в TON
+	Bounce    bool   `json:"bounce"`
+	Comment   string `json:"comment,omitempty"`
+	Payload   string `json:"payload,omitempty"`    // тело, если это не текстовый комментарий, base64 BOC
+	StateInit string `json:"state_init,omitempty"` // state-init получателя, base64 BOC
+	Message   string `json:"message"`              // внутреннее сообщение, base64 BOC
+}
+
+// offlineSubwallet - subwallet по умолчанию, как у кошельков tonutils-go
+func offlineSubwallet(walletType string) uint32 {
+	if walletType == WalletTypeV5R1Final {
+		return 0
+	}
+	return wallet.DefaultSubwallet
+}
+
+// ParsePublicKey разбирает ed25519 публичный ключ из hex
+func ParsePublicKey(key string) (ed25519.PublicKey, error) {
+	data, err := hex.DecodeString(key)
+	if err != nil || len(data) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: expected %d bytes in hex", ErrInvalidPublicKey, ed25519.PublicKeySize)
+	}
+	return data, nil
+}
+
+// ColdWalletAddress вычисляет адрес холодного кошелька по публичному ключу.
+// Seed фраза не нужна, поэтому адрес можно проверить на обеих сторонах.
+func ColdWalletAddress(key ed25519.PublicKey, walletType, network string) (*address.Address, error) {
+	if !walletHasSeqno(walletType) {
+		return nil, fmt.Errorf("%w: %s", ErrOfflineNotSupported, walletType)
+	}
+
+	globalID, ok := networkGlobalIDs[network]
+	if !ok {
+		return nil, fmt.Errorf("unknown network: %s", network)
+	}
+
+	config, err := WalletVersionConfig(walletType, globalID)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := wallet.AddressFromPubKey(key, config, offlineSubwallet(walletType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate wallet address: %w", err)
+	}
+
+	return addr, nil
+}
+
+// PrepareOfflineTransfer собирает неподписанный перевод холодного кошелька:
+// seqno, valid_until, внутренние сообщения и подписываемое тело. seqno -
+// текущий seqno контракта, но не меньше minSeqno: seqno, занятые pending
+// отправками, пропускаются, как и при отправке с сервера.
+func (s *TONService) PrepareOfflineTransfer(ctx context.Context, stored *model.Wallet, transfers []*TONTransfer, minSeqno int64, ttl time.Duration) (*OfflineTransfer, error) {
+	// У highload v3 нет seqno: query_id выделяет сервис при отправке
+	if !walletHasSeqno(stored.WalletType) {
+		return nil, fmt.Errorf("%w: %s", ErrOfflineNotSupported, stored.WalletType)
+	}
+
+	if len(transfers) == 0 {
+		return nil, fmt.Errorf("%w: no messages", ErrInvalidTransfer)
+	}
+	if limit := walletMaxMessages(stored.WalletType); len(transfers) > limit {
+		return nil, fmt.Errorf("%w: %s wallet accepts at most %d messages", ErrInvalidTransfer, stored.WalletType, limit)
+	}
+
+	net, err := s.network(stored.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := address.ParseAddr(stored.Address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stored wallet address: %w", err)
+	}
+
+	transfer := &OfflineTransfer{
+		Version:    OfflineTransferVersion,
+		WalletID:   stored.ID,
+		Address:    addr.String(),
+		PublicKey:  stored.PublicKey,
+		WalletType: stored.WalletType,
+		Network:    stored.Network,
+		Subwallet:  offlineSubwallet(stored.WalletType),
+		ValidUntil: time.Now().Add(ttl).Unix(),
+		Messages:   make([]*OfflineMessage, 0, len(transfers)),
+	}
+
+	block, err := net.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	acc, err := net.api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	if acc.IsActive && acc.State.Status == tlb.AccountStatusActive {
+		transfer.Seqno, err = getSeqno(ctx, net.api, block, addr)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		transfer.Deploy = true
+	}
+	transfer.Seqno = max(transfer.Seqno, uint32(minSeqno))
+
+	for _, t := range transfers {
+		// Для шифрования нужен приватный ключ кошелька, которого у сервера нет
+		if t.EncryptComment {
+			return nil, fmt.Errorf("%w: encrypted comments are not supported for offline transfers", ErrInvalidTransfer)
+		}
+
+		msg, err := transferMessage(t)
+		if err != nil {
+			return nil, err
+		}
+
+		described, err := describeOfflineMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		transfer.Messages = append(transfer.Messages, described)
+	}
+
+	payload, err := offlinePayload(transfer)
+	if err != nil {
+		return nil, err
+	}
+	transfer.Payload = base64.StdEncoding.EncodeToString(payload.ToBOC())
+
+	return transfer, nil
+}
+
+// SignOfflineTransfer проверяет перевод, подписывает его ключом из seed фразы
+// и заполняет Boc, MsgHash и Hash. Сеть не используется.
+func SignOfflineTransfer(transfer *OfflineTransfer, seed *Seed) error {
+	if transfer.Version != OfflineTransferVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidOfflineTransfer, transfer.Version)
+	}
+
+	globalID, ok := networkGlobalIDs[transfer.Network]
+	if !ok {
+		return fmt.Errorf("%w: unknown network %s", ErrInvalidOfflineTransfer, transfer.Network)
+	}
+
+	config, err := WalletVersionConfig(transfer.WalletType, globalID)
+	if err != nil {
+		return err
+	}
+
+	w, err := wallet.FromSeedWithPassword(nil, seed.Words, seed.Password, config)
+	if err != nil {
+		return fmt.Errorf("failed to derive key from seed: %w", err)
+	}
+	key := w.PrivateKey()
+	pub := key.Public().(ed25519.PublicKey)
+
+	if hex.EncodeToString(pub) != strings.ToLower(transfer.PublicKey) {
+		return fmt.Errorf("%w: seed does not match public key %s", ErrInvalidOfflineTransfer, transfer.PublicKey)
+	}
+
+	addr, err := wallet.AddressFromPubKey(pub, config, transfer.Subwallet)
+	if err != nil {
+		return fmt.Errorf("failed to calculate wallet address: %w", err)
+	}
+
+	dst, err := address.ParseAddr(transfer.Address)
+	if err != nil || !addr.Equals(dst) {
+		return fmt.Errorf("%w: wallet address %s does not match key, expected %s", ErrInvalidOfflineTransfer, transfer.Address, addr.String())
+	}
+
+	if validUntil := time.Unix(transfer.ValidUntil, 0); time.Now().After(validUntil) {
+		return fmt.Errorf("%w: transfer expired at %s", ErrInvalidOfflineTransfer, validUntil.UTC().Format(time.RFC3339))
+	}
+
+	if err := VerifyOfflineMessages(transfer); err != nil {
+		return err
+	}
+
+	// Подписывается тело, собранное из проверенных полей, а не присланное
+	payload, err := offlinePayload(transfer)
+	if err != nil {
+		return err
+	}
+	if transfer.Payload != "" {
+		expected, err := parseBOC(transfer.Payload)
+		if err != nil || !bytes.Equal(expected.Hash(), payload.Hash()) {
+			return fmt.Errorf("%w: payload does not match transfer fields", ErrInvalidOfflineTransfer)
+		}
+	}
+
+	body := signedWalletBody(transfer.WalletType, payload, key)
+
+	ext := &tlb.ExternalMessage{
+		DstAddr: addr,
+		Body:    body,
+	}
+	if transfer.Deploy {
+		ext.StateInit, err = wallet.GetStateInit(pub, config, transfer.Subwallet)
+		if err != nil {
+			return fmt.Errorf("failed to get state init: %w", err)
+		}
+	}
+
+	extCell, err := tlb.ToCell(ext)
+	if err != nil {
+		return fmt.Errorf("failed to serialize external message: %w", err)
+	}
+
+	transfer.Boc = base64.StdEncoding.EncodeToString(extCell.ToBOC())
+	transfer.MsgHash = hex.EncodeToString(body.Hash())
+	transfer.Hash = hex.EncodeToString(normalizedMessageHash(ext))
+
+	return nil
+}
+
+// describeOfflineMessage сериализует внутреннее сообщение вместе с описанием.
+// Тело, совпадающее с текстовым комментарием, описывается комментарием,
+// любое другое - полем Payload.
+func describeOfflineMessage(msg *wallet.Message) (*OfflineMessage, error) {
+	intMsg := msg.InternalMessage
+
+	msgCell, err := tlb.ToCell(intMsg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize message: %w", err)
+	}
+
+	described := &OfflineMessage{
+		Mode:      msg.Mode,
+		Recipient: intMsg.DstAddr.String(),
+		Amount:    intMsg.Amount.String(),
+		Bounce:    intMsg.Bounce,
+		Message:   base64.StdEncoding.EncodeToString(msgCell.ToBOC()),
+	}
+
+	if !emptyBody(intMsg.Body) {
+		comment := commentFromBody(intMsg.Body)
+		if expected, err := wallet.CreateCommentCell(comment); comment != "" && err == nil && bytes.Equal(expected.Hash(), intMsg.Body.Hash()) {
+			described.Comment = comment
+		} else {
+			described.Payload = base64.StdEncoding.EncodeToString(intMsg.Body.ToBOC())
+		}
+	}
+
+	if intMsg.StateInit != nil {
+		stateInit, err := tlb.ToCell(intMsg.StateInit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize state init: %w", err)
+		}
+		described.StateInit = base64.StdEncoding.EncodeToString(stateInit.ToBOC())
+	}
+
+	return described, nil
+}
+
+// VerifyOfflineMessages проверяет, что каждое внутреннее сообщение перевода
+// в точности соответствует описанию. Signer вызывает ее до показа перевода
+// оператору: подписывается только то, что он увидел.
+func VerifyOfflineMessages(transfer *OfflineTransfer) error {
+	for i, msg := range transfer.Messages {
+		if err := msg.verify(); err != nil {
+			return fmt.Errorf("%w: message %d: %v", ErrInvalidOfflineTransfer, i, err)
+		}
+	}
+	return nil
+}
+
+// verify проверяет, что внутреннее сообщение соответствует своему описанию:
+// получатель, сумма, bounce, тело (комментарий или payload) и state-init.
+// Сообщение, несущее что-либо сверх описания, отклоняется.
+func (m *OfflineMessage) verify() error {
+	c, err := parseBOC(m.Message)
+	if err != nil {
+		return err
+	}
+
+	var msg tlb.InternalMessage
+	if err := tlb.LoadFromCell(&msg, c.BeginParse()); err != nil {
+		return fmt.Errorf("invalid internal message: %w", err)
+	}
+
+	recipient, err := address.ParseAddr(m.Recipient)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	switch {
+	case msg.DstAddr == nil || !msg.DstAddr.Equals(recipient):
+		return errors.New("recipient does not match message")
+	case msg.Amount.String() != m.Amount:
+		return errors.New("amount does not match message")
+	case msg.Bounce != m.Bounce:
+		return errors.New("bounce flag does not match message")
+	case msg.Bounced:
+		return errors.New("message is marked as bounced")
+	case msg.ExtraCurrencies != nil && !msg.ExtraCurrencies.IsEmpty():
+		return errors.New("message carries extra currencies")
+	}
+
+	if err := m.verifyBody(msg.Body); err != nil {
+		return err
+	}
+
+	return m.verifyStateInit(msg.StateInit)
+}
+
+// verifyBody сверяет тело сообщения с комментарием или payload из описания
+func (m *OfflineMessage) verifyBody(body *cell.Cell) error {
+	var expected *cell.Cell
+	switch {
+	case m.Payload != "" && m.Comment != "":
+		return errors.New("comment and payload cannot be combined")
+	case m.Payload != "":
+		c, err := parseBOC(m.Payload)
+		if err != nil {
+			return fmt.Errorf("invalid payload: %w", err)
+		}
+		expected = c
+	case m.Comment != "":
+		c, err := wallet.CreateCommentCell(m.Comment)
+		if err != nil {
+			return fmt.Errorf("invalid comment: %w", err)
+		}
+		expected = c
+	}
+
+	if expected == nil {
+		if !emptyBody(body) {
+			return errors.New("message body is not described")
+		}
+		return nil
+	}
+
+	if emptyBody(body) || !bytes.Equal(body.Hash(), expected.Hash()) {
+		if m.Payload != "" {
+			return errors.New("payload does not match message")
+		}
+		return errors.New("comment does not match message")
+	}
+	return nil
+}
+
+// verifyStateInit сверяет state-init сообщения с описанием
+func (m *OfflineMessage) verifyStateInit(stateInit *tlb.StateInit) error {
+	if m.StateInit == "" {
+		if stateInit != nil {
+			return errors.New("message state_init is not described")
+		}
+		return nil
+	}
+
+	expected, err := parseBOC(m.StateInit)
+	if err != nil {
+		return fmt.Errorf("invalid state_init: %w", err)
+	}
+	if stateInit == nil {
+		return errors.New("state_init does not match message")
+	}
+
+	actual, err := tlb.ToCell(stateInit)
+	if err != nil || !bytes.Equal(actual.Hash(), expected.Hash()) {
+		return errors.New("state_init does not match message")
+	}
+	return nil
+}
+
+// Details описывает payload и state-init сообщения для проверки оператором:
+// опкод, размер и хеш тела, хеши кода и данных state-init
+func (m *OfflineMessage) Details() []string {
+	var details []string
+
+	if m.Payload != "" {
+		if c, err := parseBOC(m.Payload); err == nil {
+			line := fmt.Sprintf("payload: %d bits, %d refs, hash %x", c.BitsSize(), c.RefsNum(), c.Hash())
+			if c.BitsSize() >= 32 {
+				line = fmt.Sprintf("payload: op 0x%08x, %d bits, %d refs, hash %x", bodyOpcode(c), c.BitsSize(), c.RefsNum(), c.Hash())
+			}
+			details = append(details, line)
+		}
+	}
+
+	if m.StateInit != "" {
+		if c, err := parseBOC(m.StateInit); err == nil {
+			line := fmt.Sprintf("state_init: hash %x", c.Hash())
+			var stateInit tlb.StateInit
+			if err := tlb.LoadFromCell(&stateInit, c.BeginParse()); err == nil && stateInit.Code != nil {
+				line += fmt.Sprintf(", code hash %x", stateInit.Code.Hash())
+			}
+			details = append(details, line)
+		}
+	}
+
+	return details
+}
+
+// emptyBody - у сообщения нет тела (пустая ячейка или nil)
+func emptyBody(body *cell.Cell) bool {
+	return body == nil || (body.BitsSize() == 0 && body.RefsNum() == 0)
+}
+
+// offlinePayload собирает подписываемую часть тела внешнего сообщения
+// кошелька, как BuildMessage в tonutils-go, но с seqno и valid_until
+// из перевода
+func offlinePayload(transfer *OfflineTransfer) (*cell.Cell, error) {
+	messages := make([]*cell.Cell, 0, len(transfer.Messages))
+	for i, msg := range transfer.Messages {
+		c, err := parseBOC(msg.Message)
+		if err != nil {
+			return nil, fmt.Errorf("%w: message %d: %v", ErrInvalidOfflineTransfer, i, err)
+		}
+		messages = append(messages, c)
+	}
+
+	if limit := walletMaxMessages(transfer.WalletType); len(messages) > limit {
+		return nil, fmt.Errorf("%w: %s wallet accepts at most %d messages", ErrInvalidOfflineTransfer, transfer.WalletType, limit)
+	}
+
+	switch transfer.WalletType {
+	case WalletTypeV3R2, WalletTypeV4R2:
+		// subwallet_id, valid_until, seqno, [op v4], затем mode и ссылка на сообщение
+		payload := cell.BeginCell().
+			MustStoreUInt(uint64(transfer.Subwallet), 32).
+			MustStoreUInt(uint64(transfer.ValidUntil), 32).
+			MustStoreUInt(uint64(transfer.Seqno), 32)
+		if transfer.WalletType == WalletTypeV4R2 {
+			payload.MustStoreUInt(0, 8) // простой перевод
+		}
+
+		for i, msg := range messages {
+			payload.MustStoreUInt(uint64(transfer.Messages[i].Mode), 8).MustStoreRef(msg)
+		}
+		return payload.EndCell(), nil
+	case WalletTypeV5R1Final:
+		// Список действий action_send_msg#0ec3c86d, последнее сообщение - верхнее
+		list := cell.BeginCell().EndCell()
+		for i, msg := range messages {
+			list = cell.BeginCell().
+				MustStoreRef(list).
+				MustStoreUInt(0x0ec3c86d, 32).
+				MustStoreUInt(uint64(transfer.Messages[i].Mode), 8).
+				MustStoreRef(msg).
+				EndCell()
+		}
+
+		walletID := wallet.V5R1ID{
+			NetworkGlobalID: networkGlobalIDs[transfer.Network],
+			SubwalletNumber: uint16(transfer.Subwallet),
+		}
+
+		return cell.BeginCell().
+			MustStoreUInt(walletV5OpSign, 32).
+			MustStoreUInt(uint64(walletID.Serialized()), 32).
+			MustStoreUInt(uint64(transfer.ValidUntil), 32).
+			MustStoreUInt(uint64(transfer.Seqno), 32).
+			MustStoreUInt(1, 1).
+			MustStoreRef(list).
+			MustStoreUInt(0, 1).
+			EndCell(), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrOfflineNotSupported, transfer.WalletType)
+}
+
+// signedWalletBody добавляет подпись к телу: у v5 она в конце, у v3 и v4 - в начале
+func signedWalletBody(walletType string, payload *cell.Cell, key ed25519.PrivateKey) *cell.Cell {
+	signature := payload.Sign(key)
+
+	if walletType == WalletTypeV5R1Final {
+		return cell.BeginCell().
+			MustStoreBuilder(payload.ToBuilder()).
+			MustStoreSlice(signature, 512).
+			EndCell()
+	}
+
+	return cell.BeginCell().
+		MustStoreSlice(signature, 512).
+		MustStoreBuilder(payload.ToBuilder()).
+		EndCell()
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// offlineWalletTypes - типы кошельков с офлайн подписью
+var offlineWalletTypes = []string{WalletTypeV3R2, WalletTypeV4R2, WalletTypeV5R1Final}
+
+const offlineTestSeqno = 42
+
+// offlineTestWallet - кошелек tonutils-go без подключения к сети
+// и его seed фраза
+func offlineTestWallet(t *testing.T, walletType string) (*wallet.Wallet, *Seed) {
+	t.Helper()
+
+	config, err := WalletVersionConfig(walletType, networkGlobalIDs[NetworkTestnet])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seed := &Seed{Words: wallet.NewSeed()}
+	w, err := wallet.FromSeedWithPassword(nil, seed.Words, seed.Password, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr := mustColdAddress(t, w.PrivateKey(), walletType); !w.WalletAddress().Equals(addr) {
+		t.Fatalf("tonutils-go address %s differs from ColdWalletAddress %s", w.WalletAddress(), addr)
+	}
+
+	spec, ok := w.GetSpec().(seqnoSpec)
+	if !ok {
+		t.Fatalf("%s: wallet spec has no seqno", walletType)
+	}
+	spec.SetSeqnoFetcher(func(context.Context, uint32) (uint32, error) {
+		return offlineTestSeqno, nil
+	})
+
+	return w, seed
+}
+
+func mustColdAddress(t *testing.T, key ed25519.PrivateKey, walletType string) *address.Address {
+	t.Helper()
+
+	addr, err := ColdWalletAddress(key.Public().(ed25519.PublicKey), walletType, NetworkTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+// offlineTestMessages - перевод с комментарием и перевод без тела:
+// у v5 порядок действий в списке обратный, поэтому сообщений два
+func offlineTestMessages(t *testing.T) []*wallet.Message {
+	t.Helper()
+
+	var messages []*wallet.Message
+	for _, transfer := range []*TONTransfer{
+		{Recipient: randomTestAddress(t), Amount: "1.5", Comment: "offline"},
+		{Recipient: randomTestAddress(t), Amount: "0.01"},
+	} {
+		msg, err := transferMessage(transfer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+func randomTestAddress(t *testing.T) string {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mustColdAddress(t, key, WalletTypeV4R2).String()
+}
+
+// offlineTestTransfer описывает сообщения так же, как PrepareOfflineTransfer
+func offlineTestTransfer(t *testing.T, w *wallet.Wallet, walletType string, validUntil int64, messages []*wallet.Message) *OfflineTransfer {
+	t.Helper()
+
+	transfer := &OfflineTransfer{
+		Version:    OfflineTransferVersion,
+		Address:    w.WalletAddress().String(),
+		PublicKey:  hex.EncodeToString(w.PrivateKey().Public().(ed25519.PublicKey)),
+		WalletType: walletType,
+		Network:    NetworkTestnet,
+		Subwallet:  offlineSubwallet(walletType),
+		Seqno:      offlineTestSeqno,
+		ValidUntil: validUntil,
+	}
+	for _, msg := range messages {
+		described, err := describeOfflineMessage(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		transfer.Messages = append(transfer.Messages, described)
+	}
+	return transfer
+}
+
+// Тело, которое собирает offlinePayload/signedWalletBody, должно совпадать
+// с телом tonutils-go при тех же seqno и valid_until: иначе подписанное
+// офлайн сообщение кошелек отклонит
+func TestOfflinePayloadMatchesTonutils(t *testing.T) {
+	for _, walletType := range offlineWalletTypes {
+		t.Run(walletType, func(t *testing.T) {
+			w, _ := offlineTestWallet(t, walletType)
+			messages := offlineTestMessages(t)
+
+			// BuildExternalMessageForMany без сети: состояние аккаунта
+			// задано явно, кошелек уже развернут
+			ext, err := w.PrepareExternalMessageForMany(context.Background(), false, messages)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// valid_until tonutils-go берет из текущего времени, поэтому
+			// фиксируем его по собранному сообщению
+			expected, err := parseWalletExternal(walletType, ext.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected.Seqno == nil || *expected.Seqno != offlineTestSeqno {
+				t.Fatalf("seqno = %v, want %d", expected.Seqno, offlineTestSeqno)
+			}
+
+			transfer := offlineTestTransfer(t, w, walletType, expected.ValidUntil.Unix(), messages)
+			payload, err := offlinePayload(transfer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(payload.Hash(), expected.signed.Hash()) {
+				t.Fatalf("payload hash %x, tonutils-go %x", payload.Hash(), expected.signed.Hash())
+			}
+
+			body := signedWalletBody(walletType, payload, w.PrivateKey())
+			if !bytes.Equal(body.Hash(), ext.Body.Hash()) {
+				t.Fatalf("body hash %x, tonutils-go %x", body.Hash(), ext.Body.Hash())
+			}
+		})
+	}
+}
+
+// Подписанный офлайн перевод проходит ту же проверку, что и при broadcast
+func TestSignOfflineTransferRoundTrip(t *testing.T) {
+	for _, walletType := range offlineWalletTypes {
+		t.Run(walletType, func(t *testing.T) {
+			w, seed := offlineTestWallet(t, walletType)
+			validUntil := time.Now().Add(10 * time.Minute).Unix()
+
+			transfer := offlineTestTransfer(t, w, walletType, validUntil, offlineTestMessages(t))
+			if err := SignOfflineTransfer(transfer, seed); err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := parseBOC(transfer.Boc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ext tlb.ExternalMessage
+			if err := tlb.LoadFromCell(&ext, c.BeginParse()); err != nil {
+				t.Fatal(err)
+			}
+
+			if hex.EncodeToString(ext.Body.Hash()) != transfer.MsgHash {
+				t.Fatalf("msg_hash %s does not match body", transfer.MsgHash)
+			}
+			if hex.EncodeToString(normalizedMessageHash(&ext)) != transfer.Hash {
+				t.Fatalf("hash %s does not match message", transfer.Hash)
+			}
+
+			body, err := parseWalletExternal(walletType, ext.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if body.ValidUntil.Unix() != validUntil {
+				t.Fatalf("valid_until = %d, want %d", body.ValidUntil.Unix(), validUntil)
+			}
+			if body.Seqno == nil || *body.Seqno != offlineTestSeqno {
+				t.Fatalf("seqno = %v, want %d", body.Seqno, offlineTestSeqno)
+			}
+
+			stored := &model.Wallet{Address: transfer.Address, PublicKey: transfer.PublicKey}
+			if err := verifyWalletExternal(stored, body); err != nil {
+				t.Fatal(err)
+			}
+
+			// Тот же перевод не проходит проверку чужим ключом
+			other, _ := offlineTestWallet(t, walletType)
+			stored.PublicKey = hex.EncodeToString(other.PrivateKey().Public().(ed25519.PublicKey))
+			if err := verifyWalletExternal(stored, body); err == nil {
+				t.Fatal("signature verified with another wallet key")
+			}
+		})
+	}
+}
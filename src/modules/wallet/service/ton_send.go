@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// walletMessageTTL - время жизни внешнего сообщения обычных кошельков
+// (valid_until), совпадает со значением tonutils-go по умолчанию
+const walletMessageTTL = 3 * time.Minute
+
+// PreparedMessage - подписанное внешнее сообщение, готовое к отправке
+type PreparedMessage struct {
+	Address   string
+	MsgHash   string    // хеш тела внешнего сообщения, hex
+	NormHash  string    // нормализованный хеш внешнего сообщения (TEP-467), hex
+	ExpiresAt time.Time // после этого сообщение не будет принято
+	Seqno     *int64    // seqno сообщения, nil у highload v3
+	Boc       string    // подписанное внешнее сообщение, base64
+	Mode      uint8
+	Bounce    bool
+
+	ext      *tlb.ExternalMessage
+	net      *tonNetwork
+	highload *model.HighloadQuery
+}
+
+// SentTransaction - найденная транзакция кошелька по внешнему сообщению
+type SentTransaction struct {
+	Hash    string // base64
+	Lt      uint64
+	Time    time.Time
+	Amount  string // сумма первого исходящего сообщения
+	Fee     string
+	Success bool
+	Error   string
+	TxPhases
+}
+
+// TransferMessage собирает внутреннее сообщение с переводом TON. Комментарий
+// шифруется ключом получателя из его контракта, поэтому сообщение собирается
+// до очереди отправок кошелька.
+func (s *TONService) TransferMessage(ctx context.Context, stored *model.Wallet, seed *Seed, transfer *TONTransfer) (*wallet.Message, error) {
+	msg, err := transferMessage(transfer)
+	if err != nil {
+		return nil, err
+	}
+
+	if transfer.EncryptComment {
+		if err := s.encryptComment(ctx, stored, seed, msg, transfer.Comment); err != nil {
+			return nil, err
+		}
+	}
+
+	return msg, nil
+}
+
+// seqnoSpec - спецификации кошельков tonutils-go с seqno
+type seqnoSpec interface {
+	SetSeqnoFetcher(fetcher func(ctx context.Context, subWallet uint32) (uint32, error))
+}
+
+// prepareMessages подписывает сообщения одним внешним сообщением кошелька.
+// minSeqno - seqno, занятые еще не обработанными сообщениями кошелька:
+// сообщение получает max(seqno контракта, minSeqno).
+func (s *TONService) prepareMessages(ctx context.Context, stored *model.Wallet, seed *Seed, minSeqno int64, messages ...*wallet.Message) (*PreparedMessage, error) {
+	if stored.WalletType == WalletTypeHighloadV3 {
+		w, net, query, ext, err := s.prepareHighload(ctx, stored, seed, messages)
+		if err != nil {
+			return nil, err
+		}
+
+		return &PreparedMessage{
+			Address:   w.WalletAddress().String(),
+			MsgHash:   query.MsgHash,
+			NormHash:  hex.EncodeToString(normalizedMessageHash(ext)),
+			ExpiresAt: query.ExpiresAt,
+			Boc:       query.Boc,
+			ext:       ext,
+			net:       net,
+			highload:  query,
+		}, nil
+	}
+
+	w, net, err := s.openWallet(stored, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	// seqno контракта не меняется, пока предыдущее сообщение не обработано,
+	// поэтому занятые pending отправками seqno пропускаем
+	var seqno *int64
+	if spec, ok := w.GetSpec().(seqnoSpec); ok {
+		spec.SetSeqnoFetcher(func(ctx context.Context, _ uint32) (uint32, error) {
+			block, err := net.api.CurrentMasterchainInfo(ctx)
+			if err != nil {
+				return 0, fmt.Errorf("failed to get masterchain info: %w", err)
+			}
+
+			current, err := getSeqno(ctx, net.api, block, w.WalletAddress())
+			if err != nil {
+				return 0, err
+			}
+
+			value := max(int64(current), minSeqno)
+			seqno = &value
+			return uint32(value), nil
+		})
+	}
+
+	// valid_until выставляется при сборке, поэтому время берем до нее
+	expiresAt := time.Now().Add(walletMessageTTL)
+
+	ext, err := w.BuildExternalMessageForMany(ctx, messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build external message: %w", err)
+	}
+
+	extCell, err := tlb.ToCell(ext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize external message: %w", err)
+	}
+
+	return &PreparedMessage{
+		Address:   w.WalletAddress().String(),
+		MsgHash:   hex.EncodeToString(ext.Body.Hash()),
+		NormHash:  hex.EncodeToString(normalizedMessageHash(ext)),
+		ExpiresAt: expiresAt,
+		Seqno:     seqno,
+		Boc:       base64.StdEncoding.EncodeToString(extCell.ToBOC()),
+		ext:       ext,
+		net:       net,
+	}, nil
+}
+
+// Broadcast отправляет подготовленное сообщение, не дожидаясь транзакции
+func (s *TONService) Broadcast(ctx context.Context, prepared *PreparedMessage) error {
+	err := prepared.net.api.SendExternalMessage(ctx, prepared.ext)
+	if err == nil {
+		return nil
+	}
+
+	if prepared.highload != nil {
+		prepared.highload.Error = err.Error()
+		if uerr := s.highloadQueries.update(ctx, prepared.highload); uerr != nil {
+			err = errors.Join(err, uerr)
+		}
+	}
+
+	return fmt.Errorf("failed to broadcast message: %w", err)
+}
+
+// Rebroadcast повторно отправляет сохраненное внешнее сообщение кошелька.
+// Повтор безопасен: контракт не примет сообщение с уже использованным seqno.
+func (s *TONService) Rebroadcast(ctx context.Context, stored *model.Wallet, boc string) error {
+	net, err := s.network(stored.Network)
+	if err != nil {
+		return err
+	}
+
+	c, err := parseBOC(boc)
+	if err != nil {
+		return fmt.Errorf("invalid stored external message: %w", err)
+	}
+
+	var ext tlb.ExternalMessage
+	if err := tlb.LoadFromCell(&ext, c.BeginParse()); err != nil {
+		return fmt.Errorf("invalid stored external message: %w", err)
+	}
+
+	if err := net.api.SendExternalMessage(ctx, &ext); err != nil {
+		return fmt.Errorf("failed to broadcast message: %w", err)
+	}
+	return nil
+}
+
+// FindSentTransactions ищет транзакции кошелька, вызванные внешними сообщениями
+// с указанными хешами тела (hex), просматривая историю назад до времени since.
+// complete == false, если за limit транзакций до since дойти не удалось:
+// тогда отсутствие транзакции не значит, что сообщение не принято.
+func (s *TONService) FindSentTransactions(ctx context.Context, stored *model.Wallet, msgHashes []string, since time.Time, limit int) (map[string]*SentTransaction, bool, error) {
+	addr, net, err := s.walletAddress(stored, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	wanted := make(map[string]bool, len(msgHashes))
+	for _, h := range msgHashes {
+		wanted[h] = true
+	}
+
+	txList, complete, err := scanTransactionsSince(ctx, net.api, addr, since, limit)
+	if err != nil {
+		return nil, false, err
+	}
+
+	found := map[string]*SentTransaction{}
+	for _, tx := range txList {
+		if tx.IO.In == nil || tx.IO.In.MsgType != tlb.MsgTypeExternalIn {
+			continue
+		}
+
+		msgHash := hex.EncodeToString(tx.IO.In.AsExternalIn().Body.Hash())
+		if !wanted[msgHash] {
+			continue
+		}
+
+		success, reason := txResult(tx)
+		found[msgHash] = &SentTransaction{
+			Hash:     base64.StdEncoding.EncodeToString(tx.Hash),
+			Lt:       tx.LT,
+			Time:     time.Unix(int64(tx.Now), 0),
+			Amount:   sentAmount(tx, ""),
+			Fee:      txFee(tx),
+			Success:  success,
+			Error:    reason,
+			TxPhases: txPhases(tx),
+		}
+	}
+
+	return found, complete, nil
+}
+
+// TxPhases - коды фаз обычной транзакции (у служебных транзакций не заполняются)
+type TxPhases struct {
+	ComputeExitCode  *int32 `json:"compute_exit_code,omitempty"`  // exit code TVM, нет при пропуске compute фазы
+	ActionResultCode *int32 `json:"action_result_code,omitempty"` // код action фазы, нет если фаза не выполнялась
+	Aborted          bool   `json:"aborted,omitempty"`
+}
+
+func txPhases(tx *tlb.Transaction) TxPhases {
+	var phases TxPhases
+	desc, ok := tx.Description.Description.(tlb.TransactionDescriptionOrdinary)
+	if !ok {
+		return phases
+	}
+
+	if phase, ok := desc.ComputePhase.Phase.(tlb.ComputePhaseVM); ok {
+		code := phase.Details.ExitCode
+		phases.ComputeExitCode = &code
+	}
+	if desc.ActionPhase != nil {
+		code := desc.ActionPhase.ResultCode
+		phases.ActionResultCode = &code
+	}
+	phases.Aborted = desc.Aborted
+
+	return phases
+}
+
+// txResult определяет, выполнилась ли транзакция: не прервана,
+// compute и action фазы успешны
+func txResult(tx *tlb.Transaction) (bool, string) {
+	desc, ok := tx.Description.Description.(tlb.TransactionDescriptionOrdinary)
+	if !ok {
+		return true, ""
+	}
+
+	switch phase := desc.ComputePhase.Phase.(type) {
+	case tlb.ComputePhaseSkipped:
+		return false, fmt.Sprintf("compute phase skipped: %s", phase.Reason.Type)
+	case tlb.ComputePhaseVM:
+		if !phase.Success {
+			return false, fmt.Sprintf("compute phase failed: exit code %d", phase.Details.ExitCode)
+		}
+	}
+
+	if desc.ActionPhase != nil && !desc.ActionPhase.Success {
+		return false, fmt.Sprintf("action phase failed: result code %d", desc.ActionPhase.ResultCode)
+	}
+
+	if desc.Aborted {
+		return false, "transaction aborted"
+	}
+
+	return true, ""
+}
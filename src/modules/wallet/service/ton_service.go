@@ -1,37 +1,95 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"strings"
+	"time"
 
+	"github.com/uptrace/bun"
 	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/liteclient"
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/ton"
 	"github.com/xssnick/tonutils-go/ton/wallet"
 	"github.com/xssnick/tonutils-go/tvm/cell"
+	"wallet_test/src/modules/wallet/model"
 )
 
+const (
+	NetworkMainnet = "mainnet"
+	NetworkTestnet = "testnet"
+)
+
+var networkConfigURLs = map[string]string{
+	NetworkMainnet: "https://ton.org/global-config.json",
+	NetworkTestnet: "https://ton.org/testnet-global.config.json",
+}
+
+var networkGlobalIDs = map[string]int32{
+	NetworkMainnet: wallet.MainnetGlobalID,
+	NetworkTestnet: wallet.TestnetGlobalID,
+}
+
+var (
+	ErrNetworkNotConnected = errors.New("network is not connected")
+	ErrInvalidAddress      = errors.New("invalid address")
+	ErrInvalidTransfer     = errors.New("invalid transfer")
+)
+
+// tonNetwork - пул liteclient соединений к одной сети
+type tonNetwork struct {
+	client   *liteclient.ConnectionPool
+	api      ton.APIClientWrapped
+	config   *liteclient.GlobalConfig
+	globalID int32
+}
+
 type TONService struct {
-	client *liteclient.ConnectionPool
-	api    ton.APIClientWrapped
-	config *liteclient.GlobalConfig
+	networks        map[string]*tonNetwork
+	highloadQueries *highloadQueryStore
 }
 
-func NewTONService(network string) (*TONService, error) {
-	client := liteclient.NewConnectionPool()
+func NewTONService(networks []string, db *bun.DB) (*TONService, error) {
+	s := &TONService{
+		networks:        make(map[string]*tonNetwork, len(networks)),
+		highloadQueries: &highloadQueryStore{db: db},
+	}
+
+	for _, network := range networks {
+		if _, ok := s.networks[network]; ok {
+			continue
+		}
+
+		net, err := connectNetwork(network)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to %s: %w", network, err)
+		}
+		s.networks[network] = net
+	}
+
+	return s, nil
+}
 
-	configURL := "https://ton.org/global-config.json" // mainnet
-	if network == "testnet" {
-		configURL = "https://ton.org/testnet-global.config.json"
+func connectNetwork(network string) (*tonNetwork, error) {
+	configURL, ok := networkConfigURLs[network]
+	if !ok {
+		return nil, fmt.Errorf("unknown network: %s", network)
 	}
 
+	client := liteclient.NewConnectionPool()
+
 	cfg, err := liteclient.GetConfigFromUrl(context.Background(), configURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get config: %w", err)
@@ -45,90 +103,351 @@ func NewTONService(network string) (*TONService, error) {
 	api := ton.NewAPIClient(client, ton.ProofCheckPolicyFast).WithRetry()
 	api.SetTrustedBlockFromConfig(cfg)
 
-	return &TONService{
-		client: client,
-		api:    api,
-		config: cfg,
+	return &tonNetwork{
+		client:   client,
+		api:      api,
+		config:   cfg,
+		globalID: networkGlobalIDs[network],
 	}, nil
 }
 
+// network возвращает пул соединений для сети кошелька
+func (s *TONService) network(name string) (*tonNetwork, error) {
+	net, ok := s.networks[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNetworkNotConnected, name)
+	}
+
+	return net, nil
+}
+
+// HasNetwork проверяет, подключен ли сервис к сети
+func (s *TONService) HasNetwork(name string) bool {
+	_, ok := s.networks[name]
+	return ok
+}
+
 func (s *TONService) GenerateWallet() []string {
 	seed := wallet.NewSeed()
 	return seed
 }
 
-func (s *TONService) CreateWalletFromSeed(seedWords []string, walletType string) (*WalletInfo, error) {
-	config := wallet.ConfigV5R1Final{
-		NetworkGlobalID: wallet.MainnetGlobalID,
+// Seed - расшифрованная мнемоника кошелька с необязательным паролем
+type Seed struct {
+	Words    []string
+	Password string
+}
+
+// CreateWalletFromSeed вычисляет адрес кошелька. subwalletID == nil - subwallet
+// по умолчанию для версии.
+func (s *TONService) CreateWalletFromSeed(seed *Seed, walletType, network string, subwalletID *uint32) (*WalletInfo, error) {
+	net, err := s.network(network)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := WalletVersionConfig(walletType, net.globalID)
+	if err != nil {
+		return nil, err
 	}
 
-	w, err := wallet.FromSeed(s.api, seedWords, config)
+	w, err := wallet.FromSeedWithPassword(net.api, seed.Words, seed.Password, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create wallet from seed: %w", err)
 	}
 
-	address := w.WalletAddress()
+	if subwalletID != nil {
+		w, err = w.GetSubwallet(*subwalletID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive subwallet: %w", err)
+		}
+	}
 
 	return &WalletInfo{
-		Address:    address.String(),
-		SeedPhrase: strings.Join(seedWords, " "),
-		WalletType: walletType,
+		Address:         storedAddress(w.WalletAddress()),
+		PublicKey:       hex.EncodeToString(w.PrivateKey().Public().(ed25519.PublicKey)),
+		SeedPhrase:      strings.Join(seed.Words, " "),
+		WalletType:      walletType,
+		SubwalletID:     w.GetSubwalletID(),
+		NetworkGlobalID: net.globalID,
 	}, nil
 }
 
-func (s *TONService) GetBalance(ctx context.Context, seedWords []string, walletType string) (string, error) {
-	config := wallet.ConfigV5R1Final{
-		NetworkGlobalID: wallet.MainnetGlobalID,
+// PublicKeyFromSeed возвращает ed25519 публичный ключ (hex) для seed фразы.
+// Ключ не зависит от версии кошелька и сети, поэтому API не требуется.
+func PublicKeyFromSeed(seed *Seed) (string, error) {
+	w, err := wallet.FromSeedWithPassword(nil, seed.Words, seed.Password, wallet.V4R2)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive key from seed: %w", err)
+	}
+
+	return hex.EncodeToString(w.PrivateKey().Public().(ed25519.PublicKey)), nil
+}
+
+// openWallet восстанавливает кошелек из seed фразы с версией и сетью из БД
+// и проверяет, что полученный адрес совпадает с сохраненным
+func (s *TONService) openWallet(stored *model.Wallet, seed *Seed) (*wallet.Wallet, *tonNetwork, error) {
+	if seed == nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrWatchOnlyWallet, stored.Address)
 	}
 
-	w, err := wallet.FromSeed(s.api, seedWords, config)
+	net, err := s.network(stored.Network)
 	if err != nil {
-		return "", fmt.Errorf("failed to create wallet: %w", err)
+		return nil, nil, err
 	}
 
-	block, err := s.api.CurrentMasterchainInfo(ctx)
+	config, err := WalletVersionConfig(stored.WalletType, walletGlobalID(stored, net))
 	if err != nil {
-		return "", fmt.Errorf("failed to get masterchain info: %w", err)
+		return nil, nil, err
 	}
 
-	balance, err := w.GetBalance(ctx, block)
+	w, err := deriveWallet(net, stored, seed, config)
 	if err != nil {
-		return "", fmt.Errorf("failed to get balance: %w", err)
+		return nil, nil, err
 	}
 
-	return balance.String(), nil
+	return w, net, nil
 }
 
-func (s *TONService) GetWalletInfo(ctx context.Context, seedWords []string, walletType string) (*WalletDetailInfo, error) {
-	config := wallet.ConfigV5R1Final{
-		NetworkGlobalID: wallet.MainnetGlobalID,
+// storedAddress - форма адреса в таблице wallets: bounceable, без флага testnet.
+// Одна форма для всех кошельков, чтобы уникальность и поиск работали по строке.
+func storedAddress(addr *address.Address) string {
+	return addr.Bounce(true).Testnet(false).String()
+}
+
+// walletGlobalID возвращает global_id, с которым выведен адрес кошелька:
+// сохраненный в БД или global_id сети кошелька
+func walletGlobalID(stored *model.Wallet, net *tonNetwork) int32 {
+	if stored.NetworkGlobalID != nil {
+		return *stored.NetworkGlobalID
 	}
+	return net.globalID
+}
 
-	w, err := wallet.FromSeed(s.api, seedWords, config)
+// DetectNetworkGlobalID подбирает global_id, с которым выведен сохраненный
+// адрес V5R1 кошелька. Кошельки testnet, созданные до разделения global_id
+// по сетям, выведены с MainnetGlobalID.
+func (s *TONService) DetectNetworkGlobalID(stored *model.Wallet, seed *Seed) (int32, error) {
+	net, err := s.network(stored.Network)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	for _, globalID := range []int32{net.globalID, wallet.MainnetGlobalID} {
+		config, err := WalletVersionConfig(stored.WalletType, globalID)
+		if err != nil {
+			return 0, err
+		}
+
+		if _, err := deriveWallet(net, stored, seed, config); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return globalID, nil
+	}
+
+	return 0, errors.Join(errs...)
+}
+
+// deriveWallet восстанавливает кошелек с заданным конфигом версии и
+// subwallet_id из БД и проверяет, что адрес совпадает с сохраненным
+func deriveWallet(net *tonNetwork, stored *model.Wallet, seed *Seed, config wallet.VersionConfig) (*wallet.Wallet, error) {
+	w, err := wallet.FromSeedWithPassword(net.api, seed.Words, seed.Password, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create wallet: %w", err)
 	}
 
-	address := w.WalletAddress()
+	if stored.SubwalletID != nil {
+		w, err = w.GetSubwallet(uint32(*stored.SubwalletID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive subwallet: %w", err)
+		}
+	}
+
+	storedAddr, err := address.ParseAddr(stored.Address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stored wallet address: %w", err)
+	}
+
+	if !w.WalletAddress().Equals(storedAddr) {
+		return nil, fmt.Errorf("derived address %s does not match stored address %s for wallet type %s",
+			w.WalletAddress().String(), stored.Address, stored.WalletType)
+	}
+
+	return w, nil
+}
+
+// walletAddress возвращает адрес кошелька для чтения из блокчейна.
+// Для watch-only кошелька (seed == nil) используется сохраненный адрес.
+func (s *TONService) walletAddress(stored *model.Wallet, seed *Seed) (*address.Address, *tonNetwork, error) {
+	if seed != nil {
+		w, net, err := s.openWallet(stored, seed)
+		if err != nil {
+			return nil, nil, err
+		}
+		return w.WalletAddress(), net, nil
+	}
+
+	net, err := s.network(stored.Network)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addr, err := address.ParseAddr(stored.Address)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid stored wallet address: %w", err)
+	}
+
+	return addr, net, nil
+}
 
-	block, err := s.api.CurrentMasterchainInfo(ctx)
+// ResolveWatchedAddress проверяет адрес, регистрируемый как watch-only кошелек,
+// и определяет по блокчейну его версию и публичный ключ (если контракт развернут)
+func (s *TONService) ResolveWatchedAddress(ctx context.Context, rawAddr, network string) (*WatchedAddressInfo, error) {
+	net, err := s.network(network)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := address.ParseAddr(rawAddr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+	}
+
+	info := &WatchedAddressInfo{
+		Address: storedAddress(addr),
+	}
+
+	block, err := net.api.CurrentMasterchainInfo(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
 	}
 
-	balance, err := w.GetBalance(ctx, block)
+	acc, err := net.api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	info.WalletType = DetectWalletType(acc)
+	if info.WalletType == "" {
+		return info, nil
+	}
+
+	// Ключ не обязателен для чтения, поэтому ошибку get-метода игнорируем
+	if key, err := wallet.GetPublicKey(ctx, net.api, addr); err == nil {
+		info.PublicKey = hex.EncodeToString(key)
+	}
+
+	return info, nil
+}
+
+func (s *TONService) GetBalance(ctx context.Context, stored *model.Wallet, seed *Seed) (string, error) {
+	addr, net, err := s.walletAddress(stored, seed)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := net.api.CurrentMasterchainInfo(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get balance: %w", err)
+		return "", fmt.Errorf("failed to get masterchain info: %w", err)
 	}
 
-	var seqno uint32 = 0
+	acc, err := net.api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	if !acc.IsActive {
+		return tlb.ZeroCoins.String(), nil
+	}
+
+	return acc.State.Balance.String(), nil
+}
+
+func (s *TONService) GetWalletInfo(ctx context.Context, stored *model.Wallet, seed *Seed) (*WalletDetailInfo, error) {
+	address, net, err := s.walletAddress(stored, seed)
+	if err != nil {
+		return nil, err
+	}
 
-	return &WalletDetailInfo{
+	block, err := net.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	acc, err := net.api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	info := &WalletDetailInfo{
 		Address:    address.String(),
-		Balance:    balance.String(),
-		WalletType: walletType,
-		Seqno:      int64(seqno),
-	}, nil
+		Balance:    "0",
+		WalletType: stored.WalletType,
+		Status:     AccountStatus(acc),
+	}
+
+	if !acc.IsActive {
+		return info, nil
+	}
+
+	info.Balance = acc.State.Balance.String()
+	info.LastTxLt = acc.LastTxLT
+	if acc.LastTxHash != nil {
+		info.LastTxHash = base64.StdEncoding.EncodeToString(acc.LastTxHash)
+	}
+	if acc.Code != nil {
+		info.CodeHash = hex.EncodeToString(acc.Code.Hash())
+	}
+
+	// У highload v3 и неизвестных контрактов нет seqno, у неинициализированного кошелька seqno = 0
+	if acc.State.Status == tlb.AccountStatusActive && walletHasSeqno(stored.WalletType) {
+		seqno, err := getSeqno(ctx, net.api, block, address)
+		if err != nil {
+			return nil, err
+		}
+		info.Seqno = int64(seqno)
+	}
+
+	return info, nil
+}
+
+// AccountStatus возвращает статус аккаунта: uninit, active, frozen или nonexist
+func AccountStatus(acc *tlb.Account) string {
+	if !acc.IsActive || acc.State == nil {
+		return "nonexist"
+	}
+
+	switch acc.State.Status {
+	case tlb.AccountStatusActive:
+		return "active"
+	case tlb.AccountStatusUninit:
+		return "uninit"
+	case tlb.AccountStatusFrozen:
+		return "frozen"
+	default:
+		return "nonexist"
+	}
+}
+
+// getSeqno вызывает get-метод seqno контракта кошелька
+func getSeqno(ctx context.Context, api ton.APIClientWrapped, block *ton.BlockIDExt, addr *address.Address) (uint32, error) {
+	res, err := api.WaitForBlock(block.SeqNo).RunGetMethod(ctx, block, addr, "seqno")
+	if err != nil {
+		var execErr ton.ContractExecError
+		if errors.As(err, &execErr) && execErr.Code == ton.ErrCodeContractNotInitialized {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get seqno: %w", err)
+	}
+
+	seqno, err := res.Int(0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse seqno: %w", err)
+	}
+
+	return uint32(seqno.Uint64()), nil
 }
 
 func EncryptSeed(seedPhrase, encryptionKey string) (string, error) {
@@ -182,9 +501,18 @@ func DecryptSeed(encryptedSeed, encryptionKey string) (string, error) {
 }
 
 type WalletInfo struct {
+	Address         string `json:"address"`
+	PublicKey       string `json:"public_key"`
+	SeedPhrase      string `json:"seed_phrase"`
+	WalletType      string `json:"wallet_type"`
+	SubwalletID     uint32 `json:"subwallet_id"`
+	NetworkGlobalID int32  `json:"network_global_id"`
+}
+
+type WatchedAddressInfo struct {
 	Address    string `json:"address"`
-	SeedPhrase string `json:"seed_phrase"`
-	WalletType string `json:"wallet_type"`
+	PublicKey  string `json:"public_key"`
+	WalletType string `json:"wallet_type"` // пусто, если контракт не является известным кошельком
 }
 
 type WalletDetailInfo struct {
@@ -192,187 +520,500 @@ type WalletDetailInfo struct {
 	Balance    string `json:"balance"`
 	WalletType string `json:"wallet_type"`
 	Seqno      int64  `json:"seqno"`
+	Status     string `json:"status"`       // uninit, active, frozen, nonexist
+	LastTxLt   uint64 `json:"last_tx_lt"`   // lt последней транзакции
+	LastTxHash string `json:"last_tx_hash"` // хеш последней транзакции (base64)
+	CodeHash   string `json:"code_hash"`    // хеш кода контракта (hex)
 }
 
 type TransactionInfo struct {
 	Hash      string `json:"hash"`
 	Lt        uint64 `json:"lt"`
 	Timestamp int64  `json:"timestamp"`
-	Type      string `json:"type"`      // "in" или "out"
-	Amount    string `json:"amount"`    // в TON
-	Fee       string `json:"fee"`       // в TON
-	From      string `json:"from"`      // адрес отправителя
-	To        string `json:"to"`        // адрес получателя
-	Comment   string `json:"comment"`   // комментарий к транзакции
-	Success   bool   `json:"success"`   // успешна ли транзакция
+	Type      string `json:"type"`               // "in" или "out"
+	Amount    string `json:"amount"`             // в TON: входящая сумма или сумма всех исходящих сообщений
+	NetAmount string `json:"net_amount"`         // изменение баланса в TON со знаком, с учетом комиссии
+	Fee       string `json:"fee"`                // в TON
+	From      string `json:"from"`               // адрес отправителя
+	To        string `json:"to"`                 // адрес получателя (первого, если исходящих сообщений несколько)
+	Comment   string `json:"comment"`            // комментарий к транзакции
+	Success   bool   `json:"success"`            // успешна ли транзакция
+	Error     string `json:"error,omitempty"`    // причина неуспеха
+	MsgHash   string `json:"msg_hash,omitempty"` // хеш тела внешнего сообщения (hex)
+	NormHash  string `json:"-"`                  // нормализованный хеш внешнего сообщения (TEP-467, hex)
+	Bounced   bool   `json:"bounced,omitempty"`  // входящее сообщение - возврат bounce
+	BounceLt  uint64 `json:"-"`                  // created_lt входящего bounce сообщения
+	TxPhases
+
+	Messages []*TxMessageInfo    `json:"messages"`         // все внутренние сообщения транзакции
+	Jetton   *JettonTransferInfo `json:"jetton,omitempty"` // перевод жетонов (TEP-74), если распознан
+
+	encrypted    *encryptedComment   // зашифрованный комментарий входящего сообщения
+	notification *jettonNotification // transfer_notification до проверки отправителя
 }
 
-func (s *TONService) GetTransactions(ctx context.Context, seedWords []string, walletType string, limit int) ([]*TransactionInfo, error) {
-	config := wallet.ConfigV5R1Final{
-		NetworkGlobalID: wallet.MainnetGlobalID,
-	}
-
-	w, err := wallet.FromSeed(s.api, seedWords, config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create wallet: %w", err)
-	}
-
-	address := w.WalletAddress()
+// TxMessageInfo - сообщение транзакции. Хранится в transactions.messages,
+// поэтому объявлено в model.
+type TxMessageInfo = model.TxMessage
 
-	// Получаем список транзакций (lt=0, hash=nil означает "с последней транзакции")
-	txList, err := s.api.ListTransactions(ctx, address, uint32(limit), 0, nil)
-	if err != nil {
-		// Если транзакций нет, возвращаем пустой массив вместо ошибки
-		if strings.Contains(err.Error(), "no transactions were found") {
-			return []*TransactionInfo{}, nil
-		}
-		return nil, fmt.Errorf("failed to get transactions: %w", err)
+// transactionInfo разбирает транзакцию кошелька addr: все внутренние
+// сообщения и сводку по ним - направление, сумму, изменение баланса,
+// первого получателя, комментарий и перевод жетонов
+func transactionInfo(addr *address.Address, tx *tlb.Transaction) *TransactionInfo {
+	txInfo := &TransactionInfo{
+		Hash:      base64.StdEncoding.EncodeToString(tx.Hash),
+		Lt:        tx.LT,
+		Timestamp: int64(tx.Now),
+		Fee:       txFee(tx),
+		Messages:  []*TxMessageInfo{},
 	}
+	txInfo.Success, txInfo.Error = txResult(tx)
+	txInfo.TxPhases = txPhases(tx)
 
-	var transactions []*TransactionInfo
-	for _, tx := range txList {
-		txInfo := &TransactionInfo{
-			Hash:      base64.StdEncoding.EncodeToString(tx.Hash),
-			Lt:        tx.LT,
-			Timestamp: int64(tx.Now),
-			Success:   true, // По умолчанию считаем успешной
-		}
+	received, sent := new(big.Int), new(big.Int)
 
-		// Обрабатываем комиссию
-		if tx.TotalFees.Coins.Nano() != nil {
-			txInfo.Fee = tx.TotalFees.Coins.TON()
-		}
-
-		// Обрабатываем входящие сообщения
-		if tx.IO.In != nil && tx.IO.In.MsgType == tlb.MsgTypeInternal {
+	// Обрабатываем входящее сообщение
+	if tx.IO.In != nil {
+		switch tx.IO.In.MsgType {
+		case tlb.MsgTypeInternal:
 			intMsg := tx.IO.In.AsInternal()
-			if intMsg != nil {
-				txInfo.Type = "in"
-				txInfo.Amount = intMsg.Amount.TON()
-				txInfo.From = intMsg.SrcAddr.String()
-				txInfo.To = address.String()
-
-				// Пытаемся извлечь комментарий
-				if intMsg.Body != nil {
-					payload := intMsg.Body.BeginParse()
-					if op, err := payload.LoadUInt(32); err == nil && op == 0 {
-						if comment, err := payload.LoadStringSnake(); err == nil {
-							txInfo.Comment = comment
-						}
-					}
-				}
+			msg := txMessage("in", intMsg.SrcAddr, intMsg)
+			msg.Bounced = intMsg.Bounced
+			if intMsg.Bounced {
+				msg.BodyHead = bodyHead(intMsg.Body, 32)
+			}
+			if transfer := parseJettonNotification(intMsg); transfer != nil {
+				txInfo.notification = &jettonNotification{msg: msg, transfer: transfer, sender: intMsg.SrcAddr}
+			}
+			if msg.Encrypted {
+				txInfo.encrypted = &encryptedComment{msg: msg, body: intMsg.Body, sender: intMsg.SrcAddr}
 			}
+			txInfo.Messages = append(txInfo.Messages, msg)
+			received.Add(received, intMsg.Amount.Nano())
+
+			txInfo.Type = "in"
+			txInfo.Bounced = intMsg.Bounced
+			if intMsg.Bounced {
+				txInfo.BounceLt = intMsg.CreatedLT
+			}
+			txInfo.Amount = msg.Amount
+			txInfo.From = msg.Counterparty
+			txInfo.To = addr.String()
+			txInfo.Comment = msg.Comment
+		case tlb.MsgTypeExternalIn:
+			ext := tx.IO.In.AsExternalIn()
+			txInfo.MsgHash = hex.EncodeToString(ext.Body.Hash())
+			txInfo.NormHash = hex.EncodeToString(normalizedMessageHash(ext))
 		}
+	}
 
-		// Обрабатываем исходящие сообщения
-		if tx.IO.Out != nil {
-			list, err := tx.IO.Out.ToSlice()
-			if err == nil {
-				for _, msg := range list {
-					if msg.MsgType == tlb.MsgTypeInternal {
-						intMsg := msg.AsInternal()
-						if intMsg != nil {
-							txInfo.Type = "out"
-							txInfo.Amount = intMsg.Amount.TON()
-							txInfo.From = address.String()
-							txInfo.To = intMsg.DstAddr.String()
-
-							// Пытаемся извлечь комментарий
-							if intMsg.Body != nil {
-								payload := intMsg.Body.BeginParse()
-								if op, err := payload.LoadUInt(32); err == nil && op == 0 {
-									if comment, err := payload.LoadStringSnake(); err == nil {
-										txInfo.Comment = comment
-									}
-								}
-							}
-							break // Берем только первое исходящее сообщение
-						}
-					}
+	// Обрабатываем исходящие сообщения: сводка - по первому получателю
+	// и сумме всех сообщений
+	if tx.IO.Out != nil {
+		list, err := tx.IO.Out.ToSlice()
+		if err == nil {
+			for _, out := range list {
+				if out.MsgType != tlb.MsgTypeInternal {
+					continue
+				}
+				intMsg := out.AsInternal()
+				msg := txMessage("out", intMsg.DstAddr, intMsg)
+				msg.Bounce = intMsg.Bounce
+				msg.BodyHead = bodyHead(intMsg.Body, 0)
+				msg.Jetton = parseJettonTransfer(intMsg)
+				txInfo.Messages = append(txInfo.Messages, msg)
+				sent.Add(sent, intMsg.Amount.Nano())
+
+				if txInfo.Type != "out" {
+					txInfo.Type = "out"
+					txInfo.From = addr.String()
+					txInfo.To = msg.Counterparty
+					txInfo.Comment = msg.Comment
 				}
+				if msg.Jetton != nil && (txInfo.Jetton == nil || txInfo.Jetton.Direction != "out") {
+					txInfo.Jetton = msg.Jetton
+				}
+			}
+			if txInfo.Type == "out" {
+				txInfo.Amount = nanoToTON(sent)
 			}
 		}
+	}
 
-		transactions = append(transactions, txInfo)
+	net := new(big.Int).Sub(received, sent)
+	if fee := tx.TotalFees.Coins.Nano(); fee != nil {
+		net.Sub(net, fee)
 	}
+	txInfo.NetAmount = nanoToTON(net)
 
-	return transactions, nil
+	return txInfo
 }
 
-type SendTransactionResult struct {
-	Hash      string `json:"hash"`
-	Lt        uint64 `json:"lt"`
-	Address   string `json:"address"`
-	Amount    string `json:"amount"`
-	Fee       string `json:"fee"`
-	Recipient string `json:"recipient"`
-	Comment   string `json:"comment,omitempty"`
+// txMessage разбирает внутреннее сообщение: контрагент, сумма, опкод и комментарий
+func txMessage(direction string, counterparty *address.Address, msg *tlb.InternalMessage) *TxMessageInfo {
+	info := &TxMessageInfo{
+		Direction: direction,
+		Amount:    nanoToTON(msg.Amount.Nano()),
+		Comment:   commentFromBody(msg.Body),
+	}
+	if counterparty != nil {
+		info.Counterparty = counterparty.String()
+	}
+	if msg.Body != nil && msg.Body.BitsSize() >= 32 {
+		op := bodyOpcode(msg.Body)
+		info.Op = fmt.Sprintf("0x%08x", op)
+		info.Encrypted = op == wallet.EncryptedCommentOpcode
+	}
+
+	return info
 }
 
-func (s *TONService) SendTransaction(ctx context.Context, seedWords []string, walletType, recipient, amount, comment string) (*SendTransactionResult, error) {
-	config := wallet.ConfigV5R1Final{
-		NetworkGlobalID: wallet.MainnetGlobalID,
+// nanoToTON форматирует сумму в нанотонах как TON, включая отрицательные
+// значения; отсутствующая сумма - "0"
+func nanoToTON(nano *big.Int) string {
+	if nano == nil {
+		return "0"
+	}
+	if nano.Sign() < 0 {
+		return "-" + tlb.FromNanoTON(new(big.Int).Neg(nano)).TON()
 	}
+	return tlb.FromNanoTON(nano).TON()
+}
 
-	w, err := wallet.FromSeed(s.api, seedWords, config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create wallet: %w", err)
+// Режимы отправки (send_raw_msg mode): базовый режим + флаги
+const (
+	SendModeCarryInbound    = 64  // передать остаток входящего сообщения
+	SendModeCarryAllBalance = 128 // передать весь баланс, amount игнорируется
+
+	SendFlagPayFeesSeparately = 1  // комиссия сверх суммы
+	SendFlagIgnoreErrors      = 2  // игнорировать ошибки action фазы
+	SendFlagBounceOnFail      = 16 // bounce транзакции при ошибке action фазы
+	SendFlagDestroyIfZero     = 32 // удалить аккаунт при нулевом балансе
+
+	// DefaultSendMode - pay fees separately, ignore errors
+	DefaultSendMode = SendFlagPayFeesSeparately + SendFlagIgnoreErrors
+
+	sendModeKnownBits = SendModeCarryInbound | SendModeCarryAllBalance |
+		SendFlagPayFeesSeparately | SendFlagIgnoreErrors | SendFlagBounceOnFail | SendFlagDestroyIfZero
+)
+
+// TONTransfer - перевод TON одному получателю
+type TONTransfer struct {
+	Recipient      string
+	Amount         string // в TON; при SendModeCarryAllBalance игнорируется
+	Comment        string
+	Mode           *uint8 // nil - DefaultSendMode
+	Bounce         *bool  // nil - по флагу bounceable адреса получателя
+	AllowDestroy   bool   // разрешить SendFlagDestroyIfZero
+	EncryptComment bool   // зашифровать Comment ключом получателя
+	Payload        string // тело сообщения, base64 BOC; несовместимо с Comment
+	StateInit      string // state-init для деплоя получателя, base64 BOC
+}
+
+// ValidateSendMode проверяет режим отправки: известные флаги, один базовый
+// режим и явное разрешение на удаление аккаунта (+32)
+func ValidateSendMode(mode uint8, allowDestroy bool) error {
+	if mode&^sendModeKnownBits != 0 {
+		return fmt.Errorf("%w: unsupported send mode %d", ErrInvalidTransfer, mode)
+	}
+
+	if mode&SendModeCarryInbound != 0 && mode&SendModeCarryAllBalance != 0 {
+		return fmt.Errorf("%w: send modes 64 and 128 cannot be combined", ErrInvalidTransfer)
+	}
+
+	if mode&SendFlagDestroyIfZero != 0 && !allowDestroy {
+		return fmt.Errorf("%w: send mode +32 destroys the wallet account and requires allow_destroy", ErrInvalidTransfer)
+	}
+
+	return nil
+}
+
+// transferMessage собирает внутреннее сообщение с переводом TON
+func transferMessage(transfer *TONTransfer) (*wallet.Message, error) {
+	mode := uint8(DefaultSendMode)
+	if transfer.Mode != nil {
+		mode = *transfer.Mode
+	}
+
+	if err := ValidateSendMode(mode, transfer.AllowDestroy); err != nil {
+		return nil, err
 	}
 
 	// Парсим адрес получателя
-	addr, err := address.ParseAddr(recipient)
+	addr, err := address.ParseAddr(transfer.Recipient)
 	if err != nil {
-		return nil, fmt.Errorf("invalid recipient address: %w", err)
+		return nil, fmt.Errorf("%w: invalid recipient address: %v", ErrInvalidTransfer, err)
 	}
 
-	// Конвертируем сумму в TON Coins
-	coins, err := tlb.FromTON(amount)
-	if err != nil {
-		return nil, fmt.Errorf("invalid amount: %w", err)
+	// Конвертируем сумму в TON Coins; весь баланс (128) отправляется без суммы
+	if mode&SendModeCarryAllBalance == 0 && transfer.Amount == "" {
+		return nil, fmt.Errorf("%w: amount is required unless mode includes 128", ErrInvalidTransfer)
+	}
+
+	coins := tlb.ZeroCoins
+	if transfer.Amount != "" {
+		coins, err = tlb.FromTON(transfer.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid amount: %v", ErrInvalidTransfer, err)
+		}
+	}
+
+	if transfer.EncryptComment && transfer.Comment == "" {
+		return nil, fmt.Errorf("%w: encrypt_comment requires comment", ErrInvalidTransfer)
+	}
+	if transfer.Comment != "" && transfer.Payload != "" {
+		return nil, fmt.Errorf("%w: comment and payload cannot be combined", ErrInvalidTransfer)
 	}
 
-	// Создаем сообщение с комментарием (если есть)
+	// Создаем сообщение с комментарием (если есть). Зашифрованный
+	// комментарий добавляет encryptComment: нужны ключи обеих сторон
 	var body *cell.Cell
-	if comment != "" {
-		body, err = wallet.CreateCommentCell(comment)
+	if transfer.Comment != "" && !transfer.EncryptComment {
+		body, err = wallet.CreateCommentCell(transfer.Comment)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create comment: %w", err)
+			return nil, fmt.Errorf("%w: failed to create comment: %v", ErrInvalidTransfer, err)
 		}
 	}
+	if transfer.Payload != "" {
+		body, err = parseBOC(transfer.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid payload: %v", ErrInvalidTransfer, err)
+		}
+	}
+
+	var stateInit *tlb.StateInit
+	if transfer.StateInit != "" {
+		stateInit, err = parseStateInit(transfer.StateInit, addr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid state_init: %v", ErrInvalidTransfer, err)
+		}
+	}
+
+	bounce := addr.IsBounceable()
+	if transfer.Bounce != nil {
+		bounce = *transfer.Bounce
+	}
 
-	// Отправляем транзакцию
-	tx, block, err := w.SendWaitTransaction(ctx, &wallet.Message{
-		Mode: 3, // pay fees separately, ignore errors
+	return &wallet.Message{
+		Mode: mode,
 		InternalMessage: &tlb.InternalMessage{
 			IHRDisabled: true,
-			Bounce:      addr.IsBounceable(),
+			Bounce:      bounce,
 			DstAddr:     addr,
 			Amount:      coins,
 			Body:        body,
+			StateInit:   stateInit,
 		},
-	})
+	}, nil
+}
+
+// parseBOC разбирает ячейку из base64 BOC
+func parseBOC(boc string) (*cell.Cell, error) {
+	data, err := base64.StdEncoding.DecodeString(boc)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send transaction: %w", err)
+		return nil, fmt.Errorf("invalid base64: %w", err)
 	}
 
-	// Вычисляем комиссию
-	fee := "0"
-	if tx.TotalFees.Coins.Nano() != nil {
-		fee = tx.TotalFees.Coins.TON()
+	c, err := cell.FromBOC(data)
+	if err != nil {
+		return nil, err
 	}
+	return c, nil
+}
 
-	// Используем block для избежания "declared and not used"
-	_ = block
+// parseStateInit разбирает state-init из base64 BOC и проверяет, что он
+// соответствует адресу получателя: иначе сообщение не задеплоит контракт
+func parseStateInit(boc string, addr *address.Address) (*tlb.StateInit, error) {
+	c, err := parseBOC(boc)
+	if err != nil {
+		return nil, err
+	}
 
-	return &SendTransactionResult{
-		Hash:      base64.StdEncoding.EncodeToString(tx.Hash),
-		Lt:        tx.LT,
-		Address:   w.WalletAddress().String(),
-		Amount:    amount,
-		Fee:       fee,
-		Recipient: recipient,
-		Comment:   comment,
-	}, nil
+	var stateInit tlb.StateInit
+	if err := tlb.LoadFromCell(&stateInit, c.BeginParse()); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(c.Hash(), addr.Data()) {
+		return nil, fmt.Errorf("state_init hash does not match recipient address %s", addr.String())
+	}
+
+	return &stateInit, nil
+}
+
+// sentAmount возвращает сумму первого исходящего сообщения транзакции в TON.
+// Для режимов 64 и 128 она отличается от запрошенной.
+func sentAmount(tx *tlb.Transaction, requested string) string {
+	if tx.IO.Out == nil {
+		return requested
+	}
+
+	list, err := tx.IO.Out.ToSlice()
+	if err != nil || len(list) == 0 || list[0].MsgType != tlb.MsgTypeInternal {
+		return requested
+	}
+
+	return list[0].AsInternal().Amount.String()
+}
+
+// txPageSize - число транзакций в одном запросе к liteserver
+const txPageSize = 16
+
+// scanTransactions возвращает до limit транзакций аккаунта от новых к старым,
+// начиная с транзакции lt/hash (при lt == 0 - с последней транзакции аккаунта)
+func scanTransactions(ctx context.Context, api ton.APIClientWrapped, addr *address.Address, lt uint64, hash []byte, limit int) ([]*tlb.Transaction, error) {
+	return scanTransactionsAfter(ctx, api, addr, lt, hash, 0, limit)
+}
+
+// scanTransactionsAfter как scanTransactions, но останавливается
+// на транзакциях с lt <= afterLt (уже просмотренных)
+func scanTransactionsAfter(ctx context.Context, api ton.APIClientWrapped, addr *address.Address, lt uint64, hash []byte, afterLt uint64, limit int) ([]*tlb.Transaction, error) {
+	if lt == 0 {
+		block, err := api.CurrentMasterchainInfo(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+		}
+
+		acc, err := api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get account state: %w", err)
+		}
+
+		if !acc.IsActive {
+			return nil, nil
+		}
+		lt, hash = acc.LastTxLT, acc.LastTxHash
+	}
+
+	var result []*tlb.Transaction
+	for len(result) < limit && lt > afterLt {
+		list, err := api.ListTransactions(ctx, addr, uint32(min(txPageSize, limit-len(result))), lt, hash)
+		if errors.Is(err, ton.ErrNoTransactionsWereFound) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get transactions: %w", err)
+		}
+
+		// ListTransactions возвращает транзакции от старых к новым
+		for i := len(list) - 1; i >= 0; i-- {
+			if list[i].LT <= afterLt {
+				return result, nil
+			}
+			result = append(result, list[i])
+		}
+		lt, hash = list[0].PrevTxLT, list[0].PrevTxHash
+	}
+
+	return result, nil
+}
+
+// scanTransactionsSince возвращает транзакции аккаунта от новых к старым
+// не старше since, но не больше limit. complete == false, если limit
+// исчерпан раньше, чем найдены более старые транзакции или начало истории.
+func scanTransactionsSince(ctx context.Context, api ton.APIClientWrapped, addr *address.Address, since time.Time, limit int) ([]*tlb.Transaction, bool, error) {
+	var result []*tlb.Transaction
+	var lt uint64
+	var hash []byte
+	for len(result) < limit {
+		size := min(txPageSize, limit-len(result))
+		page, err := scanTransactions(ctx, api, addr, lt, hash, size)
+		if err != nil {
+			return nil, false, err
+		}
+
+		for _, tx := range page {
+			if int64(tx.Now) < since.Unix() {
+				return result, true, nil
+			}
+			result = append(result, tx)
+		}
+
+		if len(page) < size || page[len(page)-1].PrevTxLT == 0 {
+			return result, true, nil
+		}
+		lt, hash = page[len(page)-1].PrevTxLT, page[len(page)-1].PrevTxHash
+	}
+
+	return result, false, nil
+}
+
+// rawAddress возвращает адрес в raw формате (workchain:hex), не зависящем от флагов
+func rawAddress(addr *address.Address) string {
+	return fmt.Sprintf("%d:%x", addr.Workchain(), addr.Data())
+}
+
+// randomQueryID генерирует случайный query_id для сообщений контрактам
+func randomQueryID() (uint64, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return 0, fmt.Errorf("failed to generate query id: %w", err)
+	}
+	return binary.LittleEndian.Uint64(buf), nil
+}
+
+// txFee возвращает общую комиссию транзакции в TON
+func txFee(tx *tlb.Transaction) string {
+	return nanoToTON(tx.TotalFees.Coins.Nano())
+}
+
+// bodyOpcode возвращает опкод тела сообщения или 0, если его нет
+func bodyOpcode(body *cell.Cell) uint64 {
+	op, err := body.BeginParse().LoadUInt(32)
+	if err != nil {
+		return 0
+	}
+	return op
+}
+
+// bounceBodyBits - сколько бит исходного тела копирует bounce сообщение
+const bounceBodyBits = 256
+
+// bodyHead возвращает до bounceBodyBits бит тела после skip бит в виде
+// "число_бит:hex". По нему возврат сопоставляется с исходным сообщением:
+// тело bounce - 0xffffffff и первые 256 бит исходного тела.
+func bodyHead(body *cell.Cell, skip uint) string {
+	if body == nil {
+		return "0:"
+	}
+
+	payload := body.BeginParse()
+	if payload.BitsLeft() < skip {
+		return ""
+	}
+	if _, err := payload.LoadSlice(skip); err != nil {
+		return ""
+	}
+
+	size := min(payload.BitsLeft(), bounceBodyBits)
+	data, err := payload.LoadSlice(size)
+	if err != nil {
+		return ""
+	}
+	// Биты после конца данных в последнем байте не сравниваем
+	if rest := size % 8; rest != 0 {
+		data[len(data)-1] &= 0xff << (8 - rest)
+	}
+
+	return fmt.Sprintf("%d:%x", size, data)
+}
+
+// commentFromBody извлекает текстовый комментарий (op = 0) из тела сообщения
+func commentFromBody(body *cell.Cell) string {
+	if body == nil {
+		return ""
+	}
+
+	payload := body.BeginParse()
+	if op, err := payload.LoadUInt(32); err != nil || op != 0 {
+		return ""
+	}
+
+	comment, err := payload.LoadStringSnake()
+	if err != nil {
+		return ""
+	}
+	return comment
 }
 
 func TONAmount(amount string) (tlb.Coins, error) {
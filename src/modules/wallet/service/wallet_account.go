@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+
+	"github.com/xssnick/tonutils-go/address"
+)
+
+// GetAccount возвращает состояние аккаунта по адресу и, если адрес
+// принадлежит кошельку сервиса, его ID
+func (s *WalletService) GetAccount(ctx context.Context, rawAddr, network string) (*AccountInfo, error) {
+	info, err := s.tonService.GetAccount(ctx, rawAddr, network)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := address.ParseAddr(info.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.managedWallet(ctx, addr, network)
+	if err != nil {
+		return nil, err
+	}
+	if wallet != nil {
+		info.WalletID = wallet.ID
+	}
+
+	return info, nil
+}
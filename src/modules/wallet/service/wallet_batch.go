@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// SendBatch отправляет переводы частями по максимальному для версии кошелька
+// числу сообщений, не дожидаясь транзакций. Каждая часть - pending отправка
+// в очереди кошелька со своим seqno, ее статус обновляет TrackPendingSends.
+// Сеть принимает части по одной, по мере обработки предыдущего seqno: до
+// этого их повторяет трекер, а не дождавшиеся своей очереди до valid_until
+// части становятся failed. Если часть не удалось подписать или записать,
+// остальные не отправляются, чтобы повтор запроса не задвоил выплаты.
+func (s *WalletService) SendBatch(ctx context.Context, walletID int64, transfers []*TONTransfer) (*BatchSendResult, error) {
+	stored, err := s.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.IsWatchOnly {
+		return nil, fmt.Errorf("%w: %s", ErrWatchOnlyWallet, stored.Address)
+	}
+
+	seed, err := s.decryptSeed(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверяем все переводы до отправки первой части
+	messages := make([]*wallet.Message, 0, len(transfers))
+	for i, t := range transfers {
+		msg, err := transferMessage(t)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send batch: message %d: %w", i, err)
+		}
+		messages = append(messages, msg)
+	}
+
+	chunkSize := walletMaxMessages(stored.WalletType)
+	result := &BatchSendResult{
+		Address:  stored.Address,
+		Messages: make([]*BatchMessageResult, 0, len(transfers)),
+	}
+
+	var failed bool
+	for start := 0; start < len(messages); start += chunkSize {
+		end := min(start+chunkSize, len(messages))
+
+		chunk := &BatchChunkResult{
+			Index:  len(result.Chunks),
+			Size:   end - start,
+			Status: BatchStatusSkipped,
+		}
+		result.Chunks = append(result.Chunks, chunk)
+
+		if !failed {
+			failed = !s.sendChunk(ctx, stored, seed, chunk, transfers[start], messages[start:end])
+		}
+
+		for i := start; i < end; i++ {
+			result.Messages = append(result.Messages, &BatchMessageResult{
+				Index:     i,
+				Chunk:     chunk.Index,
+				Recipient: transfers[i].Recipient,
+				Amount:    transfers[i].Amount,
+				Comment:   transfers[i].Comment,
+				Status:    chunk.Status,
+				SendID:    chunk.SendID,
+				MsgHash:   chunk.MsgHash,
+				Error:     chunk.Error,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// sendChunk записывает часть pending отправкой и отправляет ее. В записи -
+// первый получатель и сумма всех сообщений, как в сводке индексатора.
+// Возвращает false, если часть не записана.
+func (s *WalletService) sendChunk(ctx context.Context, stored *model.Wallet, seed *Seed, chunk *BatchChunkResult, first *TONTransfer, messages []*wallet.Message) bool {
+	total := new(big.Int)
+	for _, msg := range messages {
+		total.Add(total, msg.InternalMessage.Amount.Nano())
+	}
+
+	tx := &model.Transaction{
+		WalletID:    stored.ID,
+		FromAddress: stored.Address,
+		ToAddress:   first.Recipient,
+		Amount:      nanoToTON(total),
+		Status:      TxStatusPending,
+		Direction:   "out",
+	}
+
+	prepared, err := s.submitSend(ctx, stored, seed, tx, messages...)
+	if prepared == nil {
+		chunk.Status = BatchStatusFailed
+		chunk.Error = err.Error()
+		return false
+	}
+
+	// Ошибка отправки не останавливает пакет: часть записана, и трекер
+	// повторяет ее до истечения valid_until
+	chunk.Status = BatchStatusPending
+	chunk.SendID = tx.ID
+	chunk.MsgHash = tx.MsgHash
+	chunk.ExpiresAt = &tx.ExpiresAt
+	if err != nil {
+		chunk.Error = err.Error()
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// bounceMatchLimit - сколько исходящих транзакций перед возвратом
+// проверяется при поиске исходного перевода
+const bounceMatchLimit = 50
+
+// linkBounces связывает проиндексированные возвраты bounce с исходными
+// переводами кошелька. Исходный перевод - последняя успешная исходящая
+// транзакция, созданная раньше bounce сообщения, с сообщением на адрес
+// отправителя возврата, начало тела которого совпадает с телом возврата, а сумма
+// не меньше возвращенной. Он получает статус bounced, а перевод после добавления
+// кошелька в сервис - событие send.bounced. Возвраты, исходная транзакция
+// которых еще не загружена (догрузка истории), связываются на следующих проходах.
+func (s *WalletService) linkBounces(ctx context.Context, db bun.IDB, wallet *model.Wallet) error {
+	var bounces []*model.Transaction
+	err := db.NewSelect().
+		Model(&bounces).
+		Where("t.wallet_id = ?", wallet.ID).
+		Where("t.bounced").
+		Where("t.bounce_of IS NULL").
+		Order("t.lt").
+		Scan(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get bounces: %w", err)
+	}
+
+	for _, bounce := range bounces {
+		original, err := s.bouncedTransfer(ctx, db, bounce)
+		if err != nil {
+			return err
+		}
+		if original == nil {
+			continue
+		}
+
+		original.Status = TxStatusBounced
+		original.BounceTxHash = bounce.TxHash
+		original.UpdatedAt = time.Now()
+		_, err = db.NewUpdate().
+			Model(original).
+			Column("status", "bounce_tx_hash", "updated_at").
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to update transaction: %w", err)
+		}
+
+		bounce.BounceOf = original.TxHash
+		bounce.UpdatedAt = time.Now()
+		_, err = db.NewUpdate().
+			Model(bounce).
+			Column("bounce_of", "updated_at").
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to update transaction: %w", err)
+		}
+
+		if !original.TxTime.Before(wallet.CreatedAt) {
+			if err := s.emitSendResult(ctx, db, original); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// bouncedTransfer ищет исходный перевод возврата bounce или возвращает nil
+func (s *WalletService) bouncedTransfer(ctx context.Context, db bun.IDB, bounce *model.Transaction) (*model.Transaction, error) {
+	sender, err := address.ParseAddr(bounce.FromAddress)
+	if err != nil {
+		return nil, nil
+	}
+
+	// Исходное сообщение создано раньше bounce сообщения
+	beforeLt := bounce.BounceLt
+	if beforeLt == 0 {
+		beforeLt = bounce.Lt
+	}
+
+	var candidates []*model.Transaction
+	err = db.NewSelect().
+		Model(&candidates).
+		Where("t.wallet_id = ?", bounce.WalletID).
+		Where("t.direction = ?", "out").
+		Where("t.status = ?", TxStatusConfirmed).
+		Where("t.lt < ?", beforeLt).
+		OrderExpr("t.lt DESC").
+		Limit(bounceMatchLimit).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	var returned *model.TxMessage
+	for _, msg := range bounce.Messages {
+		if msg.Direction == "in" && msg.Bounced {
+			returned = msg
+		}
+	}
+
+	for _, tx := range candidates {
+		if sentTo(tx, rawAddress(sender), returned) {
+			return tx, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// sentTo проверяет, есть ли у транзакции исходящее сообщение на адрес raw,
+// которое могло вернуться сообщением returned: начало тела совпадает, сумма
+// не меньше возвращенной. У строк без сообщений (до их сохранения)
+// проверяется только to_address, у сообщений без body_head - адрес и сумма.
+func sentTo(tx *model.Transaction, raw string, returned *model.TxMessage) bool {
+	if len(tx.Messages) == 0 {
+		addr, err := address.ParseAddr(tx.ToAddress)
+		return err == nil && rawAddress(addr) == raw
+	}
+
+	for _, msg := range tx.Messages {
+		if msg.Direction != "out" {
+			continue
+		}
+
+		addr, err := address.ParseAddr(msg.Counterparty)
+		if err != nil || rawAddress(addr) != raw {
+			continue
+		}
+		if returned == nil {
+			return true
+		}
+		if msg.BodyHead != "" && returned.BodyHead != "" && msg.BodyHead != returned.BodyHead {
+			continue
+		}
+		if tonLess(msg.Amount, returned.Amount) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// tonLess сравнивает суммы в TON; нераспознанные суммы не сравниваются
+func tonLess(a, b string) bool {
+	x, err := tlb.FromTON(a)
+	if err != nil {
+		return false
+	}
+	y, err := tlb.FromTON(b)
+	if err != nil {
+		return false
+	}
+	return x.Nano().Cmp(y.Nano()) < 0
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/uptrace/bun"
+	"github.com/xssnick/tonutils-go/address"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// BroadcastResult - результат отправки подписанного внешнего сообщения
+type BroadcastResult struct {
+	*ExternalMessage
+	Send *model.Transaction // pending отправка, если получатель - кошелек сервиса
+}
+
+// BroadcastMessage отправляет внешнее сообщение, подписанное вне сервиса.
+// Если сообщение адресовано кошельку сервиса, проверяется подпись его ключом
+// и записывается pending отправка, статус которой обновляет TrackPendingSends.
+// Сообщения кошельков без известного ключа (watch-only) отправляются без
+// записи: иначе кто угодно мог бы создавать их pending отправки.
+// Если отправка записана, а в сеть сообщение не ушло, возвращается и
+// результат, и ошибка: запись остается pending, ее повторяет трекер.
+func (s *WalletService) BroadcastMessage(ctx context.Context, boc, network string) (*BroadcastResult, error) {
+	msg, err := s.tonService.ParseExternalMessage(boc, network)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BroadcastResult{ExternalMessage: msg}
+
+	wallet, err := s.managedWallet(ctx, msg.Destination, network)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return result, s.tonService.BroadcastExternal(ctx, msg)
+	}
+
+	expiresAt, seqno, err := s.tonService.ValidateWalletExternal(wallet, msg)
+	if errors.Is(err, errWalletKeyUnknown) {
+		return result, s.tonService.BroadcastExternal(ctx, msg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &model.Transaction{
+		WalletID:    wallet.ID,
+		MsgHash:     msg.MsgHash,
+		NormHash:    msg.Hash,
+		FromAddress: wallet.Address,
+		Amount:      "0",
+		Status:      TxStatusPending,
+		Direction:   "out",
+		ExpiresAt:   expiresAt,
+		Seqno:       seqno,
+		Boc:         boc,
+	}
+
+	// Запись под той же блокировкой кошелька, что и у submitSend: иначе
+	// параллельная отправка сервиса не увидела бы занятый сообщением seqno
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, db bun.Tx) error {
+		_, err := db.NewSelect().
+			Model((*model.Wallet)(nil)).
+			Column("id").
+			Where("id = ?", wallet.ID).
+			For("UPDATE").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to lock wallet: %w", err)
+		}
+
+		// Повторная отправка того же сообщения возвращает уже записанную отправку
+		res, err := db.NewInsert().
+			Model(tx).
+			On("CONFLICT (msg_hash) DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to save transaction: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			err := db.NewSelect().
+				Model(tx).
+				Where("msg_hash = ?", msg.MsgHash).
+				Scan(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get transaction: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Send = tx
+
+	if err := s.tonService.BroadcastExternal(ctx, msg); err != nil {
+		if tx.Status == TxStatusPending {
+			tx.Error = err.Error()
+			if uerr := s.updateSend(ctx, tx); uerr != nil {
+				err = errors.Join(err, uerr)
+			}
+		}
+		return result, err
+	}
+
+	return result, nil
+}
+
+// managedWallet ищет активный кошелек сервиса по адресу в любом формате.
+// Возвращает nil, если адрес не принадлежит сервису.
+func (s *WalletService) managedWallet(ctx context.Context, addr *address.Address, network string) (*model.Wallet, error) {
+	wallet := new(model.Wallet)
+	err := s.db.NewSelect().
+		Model(wallet).
+		Where("address = ?", storedAddress(addr)).
+		Where("network = ?", network).
+		Where("is_active = ?", true).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+
+	return wallet, nil
+}
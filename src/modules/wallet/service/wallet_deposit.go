@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/xssnick/tonutils-go/tlb"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// DepositEvent - данные события deposit.received
+type DepositEvent struct {
+	WalletID  int64                 `json:"wallet_id"`
+	Address   string                `json:"address"`
+	TxHash    string                `json:"tx_hash"`
+	Lt        uint64                `json:"lt"`
+	Amount    string                `json:"amount"` // в TON
+	Sender    string                `json:"sender"`
+	Comment   string                `json:"comment,omitempty"`
+	Timestamp int64                 `json:"timestamp"`
+	Jetton    *model.JettonTransfer `json:"jetton,omitempty"` // уведомление о переводе жетонов от jetton-кошелька получателя
+}
+
+// depositFromTransaction возвращает депозит, если транзакция - успешный
+// входящий перевод TON (не возврат bounce) после добавления кошелька в сервис.
+// Более ранняя история, загруженная индексатором, депозитами не считается.
+// Жетоны попадают в депозит, только если отправитель уведомления проверен
+// как jetton-кошелек получателя (известен master): иначе сумму в уведомлении
+// мог указать любой контракт.
+func depositFromTransaction(wallet *model.Wallet, info *TransactionInfo) *model.Deposit {
+	if info.Type != "in" || !info.Success || info.Bounced {
+		return nil
+	}
+
+	txTime := time.Unix(info.Timestamp, 0)
+	if txTime.Before(wallet.CreatedAt) {
+		return nil
+	}
+
+	amount, err := tlb.FromTON(info.Amount)
+	if err != nil || amount.Nano().Sign() <= 0 {
+		return nil
+	}
+
+	deposit := &model.Deposit{
+		WalletID: wallet.ID,
+		TxHash:   info.Hash,
+		Lt:       info.Lt,
+		Amount:   info.Amount,
+		Sender:   info.From,
+		Comment:  info.Comment,
+		TxTime:   txTime,
+	}
+	if info.Jetton != nil && info.Jetton.Master != "" {
+		deposit.Jetton = info.Jetton
+	}
+
+	return deposit
+}
+
+// recordDeposit сохраняет депозит и событие deposit.received.
+// Повторно найденная транзакция игнорируется, событие по ней не создается.
+func (s *WalletService) recordDeposit(ctx context.Context, db bun.IDB, wallet *model.Wallet, deposit *model.Deposit) error {
+	res, err := db.NewInsert().
+		Model(deposit).
+		On("CONFLICT (tx_hash) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to save deposit: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+
+	return s.webhooks.Emit(ctx, db, EventDepositReceived, &DepositEvent{
+		WalletID:  wallet.ID,
+		Address:   wallet.Address,
+		TxHash:    deposit.TxHash,
+		Lt:        deposit.Lt,
+		Amount:    deposit.Amount,
+		Sender:    deposit.Sender,
+		Comment:   deposit.Comment,
+		Timestamp: deposit.TxTime.Unix(),
+		Jetton:    deposit.Jetton,
+	})
+}
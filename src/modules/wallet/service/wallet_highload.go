@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"wallet_test/src/modules/wallet/model"
+)
+
+var (
+	ErrNotHighloadWallet     = errors.New("wallet is not a HighloadV3 wallet")
+	ErrHighloadQueryNotFound = errors.New("highload query not found")
+)
+
+// ListHighloadQueries возвращает отправленные сообщения highload кошелька, новые первыми
+func (s *WalletService) ListHighloadQueries(ctx context.Context, walletID int64, status string, limit int) ([]*model.HighloadQuery, error) {
+	wallet, err := s.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallet.WalletType != WalletTypeHighloadV3 {
+		return nil, fmt.Errorf("%w: %s", ErrNotHighloadWallet, wallet.WalletType)
+	}
+
+	var queries []*model.HighloadQuery
+	q := s.db.NewSelect().
+		Model(&queries).
+		Where("wallet_id = ?", walletID).
+		Order("id DESC").
+		Limit(limit)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list highload queries: %w", err)
+	}
+
+	return queries, nil
+}
+
+// RetryHighloadQuery повторно отправляет pending сообщение highload кошелька
+// или уточняет его статус. Новый query_id при этом не выделяется.
+func (s *WalletService) RetryHighloadQuery(ctx context.Context, walletID, queryID int64) (*model.HighloadQuery, error) {
+	wallet, err := s.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallet.WalletType != WalletTypeHighloadV3 {
+		return nil, fmt.Errorf("%w: %s", ErrNotHighloadWallet, wallet.WalletType)
+	}
+
+	query := new(model.HighloadQuery)
+	err = s.db.NewSelect().
+		Model(query).
+		Where("id = ?", queryID).
+		Where("wallet_id = ?", walletID).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %d", ErrHighloadQueryNotFound, queryID)
+	}
+
+	if err := s.tonService.RetryHighloadQuery(ctx, wallet, query); err != nil {
+		return nil, fmt.Errorf("failed to retry highload query: %w", err)
+	}
+
+	return query, nil
+}
+
+// TrackHighloadQueries уточняет статус истекших pending сообщений:
+// после истечения сообщение уже не будет принято, и ответ контракта окончателен
+func (s *WalletService) TrackHighloadQueries(ctx context.Context) (int, error) {
+	var queries []*model.HighloadQuery
+	err := s.db.NewSelect().
+		Model(&queries).
+		Relation("Wallet").
+		Where("hq.status = ?", HighloadQueryPending).
+		Where("hq.expires_at < ?", time.Now().Add(-highloadClockSkew*time.Second)).
+		Order("hq.id").
+		Scan(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending highload queries: %w", err)
+	}
+
+	var errs []error
+	resolved := 0
+	for _, query := range queries {
+		if err := s.tonService.RefreshHighloadQuery(ctx, query.Wallet, query); err != nil {
+			errs = append(errs, fmt.Errorf("query %d: %w", query.ID, err))
+			continue
+		}
+		if query.Status != HighloadQueryPending {
+			resolved++
+		}
+	}
+
+	return resolved, errors.Join(errs...)
+}
+
+// RunHighloadTracker периодически вызывает TrackHighloadQueries до отмены ctx
+func (s *WalletService) RunHighloadTracker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			resolved, err := s.TrackHighloadQueries(ctx)
+			if err != nil {
+				log.Printf("Highload query tracking finished with errors: %v", err)
+			}
+			if resolved > 0 {
+				log.Printf("Highload queries resolved: %d", resolved)
+			}
+		}
+	}
+}
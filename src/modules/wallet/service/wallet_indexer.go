@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// TransactionFilter - фильтры истории транзакций кошелька
+type TransactionFilter struct {
+	From      time.Time // не раньше (нулевое значение - без ограничения)
+	To        time.Time // раньше (нулевое значение - без ограничения)
+	Direction string    // in, out
+	Status    string    // pending, confirmed, failed
+	Cursor    string    // next_cursor предыдущей страницы
+	Limit     int
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// GetTransactions возвращает страницу проиндексированной истории кошелька из БД,
+// новые первыми, и курсор следующей страницы (пустой на последней).
+// Отправки, транзакция которых еще не найдена, идут в начале списка.
+func (s *WalletService) GetTransactions(ctx context.Context, walletID int64, filter *TransactionFilter) ([]*model.Transaction, string, error) {
+	var transactions []*model.Transaction
+	q := s.db.NewSelect().
+		Model(&transactions).
+		Where("t.wallet_id = ?", walletID).
+		OrderExpr("t.lt DESC NULLS FIRST, COALESCE(t.tx_hash, t.msg_hash) DESC").
+		Limit(filter.Limit + 1)
+
+	if filter.Cursor != "" {
+		lt, hash, err := decodeTxCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if lt == 0 {
+			// Курсор на отправке без транзакции: дальше остальные такие
+			// отправки и вся проиндексированная история
+			q = q.Where("(t.lt IS NULL AND t.msg_hash < ?) OR t.lt IS NOT NULL", hash)
+		} else {
+			q = q.Where("t.lt < ? OR (t.lt = ? AND t.tx_hash < ?)", lt, lt, hash)
+		}
+	}
+
+	// У pending отправок времени транзакции еще нет
+	if !filter.From.IsZero() {
+		q = q.Where("COALESCE(t.tx_time, t.created_at) >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		q = q.Where("COALESCE(t.tx_time, t.created_at) < ?", filter.To)
+	}
+	if filter.Direction != "" {
+		q = q.Where("t.direction = ?", filter.Direction)
+	}
+	if filter.Status != "" {
+		q = q.Where("t.status = ?", filter.Status)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, "", fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	next := ""
+	if len(transactions) > filter.Limit {
+		transactions = transactions[:filter.Limit]
+		next = encodeTxCursor(transactions[len(transactions)-1])
+	}
+
+	return transactions, next, nil
+}
+
+// encodeTxCursor кодирует позицию транзакции в истории: lt и хеш транзакции,
+// для отправок без транзакции - 0 и хеш внешнего сообщения
+func encodeTxCursor(tx *model.Transaction) string {
+	hash := tx.TxHash
+	if tx.Lt == 0 {
+		hash = tx.MsgHash
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(tx.Lt, 10) + ":" + hash))
+}
+
+func decodeTxCursor(cursor string) (uint64, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+
+	ltStr, hash, ok := strings.Cut(string(raw), ":")
+	if !ok || hash == "" {
+		return 0, "", ErrInvalidCursor
+	}
+
+	lt, err := strconv.ParseUint(ltStr, 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+
+	return lt, hash, nil
+}
+
+// IndexWallets загружает новые транзакции активных кошельков в таблицу
+// transactions, начиная с последнего проиндексированного lt
+func (s *WalletService) IndexWallets(ctx context.Context) (int, error) {
+	var wallets []*model.Wallet
+	err := s.db.NewSelect().
+		Model(&wallets).
+		Where("is_active = ?", true).
+		Order("id").
+		Scan(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get wallets: %w", err)
+	}
+
+	var errs []error
+	indexed := 0
+	for _, wallet := range wallets {
+		n, err := s.indexWallet(ctx, wallet)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("wallet %d: %w", wallet.ID, err))
+			continue
+		}
+		indexed += n
+	}
+
+	return indexed, errors.Join(errs...)
+}
+
+// indexWallet загружает страницу новых транзакций кошелька и очередную
+// страницу старой истории, если она еще не загружена полностью. Страница
+// истории загружается на каждом проходе, даже если есть новые транзакции:
+// иначе у активного кошелька старая история не догрузилась бы никогда.
+// Если новых транзакций больше страницы (например, после простоя), пропуск
+// догружается по странице за проход от новых к старым, начиная с catchup_lt;
+// last_indexed_lt сдвигается на catchup_top_lt, когда пропуск загружен.
+func (s *WalletService) indexWallet(ctx context.Context, wallet *model.Wallet) (int, error) {
+	catchupLt, catchupHash, catchupTopLt := wallet.CatchupLt, wallet.CatchupHash, wallet.CatchupTopLt
+
+	var fromHash []byte
+	if catchupLt != 0 {
+		hash, err := base64.StdEncoding.DecodeString(catchupHash)
+		if err != nil {
+			return 0, fmt.Errorf("invalid catchup hash: %w", err)
+		}
+		fromHash = hash
+	}
+
+	page, err := s.tonService.IndexTransactions(ctx, wallet, catchupLt, fromHash, wallet.LastIndexedLt)
+	if err != nil {
+		return 0, err
+	}
+
+	lastLt, historyLt, historyHash := wallet.LastIndexedLt, wallet.HistoryLt, wallet.HistoryHash
+	transactions := page.Transactions
+	if catchupLt == 0 && len(transactions) > 0 {
+		catchupTopLt = transactions[0].Lt
+	}
+
+	switch {
+	case wallet.LastIndexedLt == 0:
+		// Первая индексация: остальная история догружается со следующего прохода
+		if len(transactions) > 0 {
+			lastLt = catchupTopLt
+			historyLt, historyHash = page.PrevLt, base64.StdEncoding.EncodeToString(page.PrevHash)
+		}
+		catchupTopLt = 0
+	case page.PrevLt > wallet.LastIndexedLt:
+		// Страница не дошла до last_indexed_lt: продолжаем со следующего прохода
+		catchupLt, catchupHash = page.PrevLt, base64.StdEncoding.EncodeToString(page.PrevHash)
+	default:
+		if catchupTopLt != 0 {
+			lastLt = catchupTopLt
+		}
+		catchupLt, catchupHash, catchupTopLt = 0, "", 0
+	}
+
+	if wallet.HistoryLt != 0 {
+		hash, err := base64.StdEncoding.DecodeString(wallet.HistoryHash)
+		if err != nil {
+			return 0, fmt.Errorf("invalid history hash: %w", err)
+		}
+
+		history, err := s.tonService.IndexHistory(ctx, wallet, wallet.HistoryLt, hash)
+		if err != nil {
+			return 0, err
+		}
+		transactions = append(transactions, history.Transactions...)
+		historyLt, historyHash = history.PrevLt, base64.StdEncoding.EncodeToString(history.PrevHash)
+	}
+
+	if historyLt == 0 {
+		historyHash = ""
+	}
+
+	if lastLt == wallet.LastIndexedLt && historyLt == wallet.HistoryLt && catchupLt == wallet.CatchupLt {
+		// Возвраты, исходный перевод которых не был найден, связываются
+		// и на проходах без новых транзакций
+		err := s.db.RunInTx(ctx, nil, func(ctx context.Context, db bun.Tx) error {
+			return s.linkBounces(ctx, db, wallet)
+		})
+		return 0, err
+	}
+
+	// Зашифрованные комментарии расшифровываются ключом кошелька;
+	// у watch-only кошельков они остаются зашифрованными
+	if HasEncryptedComments(transactions) && !wallet.IsWatchOnly {
+		seed, err := s.decryptSeed(wallet)
+		if err != nil {
+			return 0, err
+		}
+		if err := s.tonService.DecryptComments(ctx, wallet, seed, transactions); err != nil {
+			return 0, err
+		}
+	}
+
+	// Строки и новые указатели сохраняются вместе: при ошибке следующий
+	// проход повторит тот же диапазон
+	err = s.db.RunInTx(ctx, nil, func(ctx context.Context, db bun.Tx) error {
+		for _, info := range transactions {
+			if err := s.upsertTransaction(ctx, db, indexedTransaction(wallet.ID, info)); err != nil {
+				return err
+			}
+
+			if deposit := depositFromTransaction(wallet, info); deposit != nil {
+				if err := s.recordDeposit(ctx, db, wallet, deposit); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := s.linkBounces(ctx, db, wallet); err != nil {
+			return err
+		}
+
+		_, err := db.NewUpdate().
+			Model((*model.Wallet)(nil)).
+			Set("last_indexed_lt = ?", lastLt).
+			Set("history_lt = ?", historyLt).
+			Set("history_hash = ?", historyHash).
+			Set("catchup_lt = ?", catchupLt).
+			Set("catchup_hash = ?", catchupHash).
+			Set("catchup_top_lt = ?", catchupTopLt).
+			Where("id = ?", wallet.ID).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to update indexed lt: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	wallet.LastIndexedLt, wallet.HistoryLt, wallet.HistoryHash = lastLt, historyLt, historyHash
+	wallet.CatchupLt, wallet.CatchupHash, wallet.CatchupTopLt = catchupLt, catchupHash, catchupTopLt
+
+	return len(transactions), nil
+}
+
+func indexedTransaction(walletID int64, info *TransactionInfo) *model.Transaction {
+	tx := &model.Transaction{
+		WalletID:    walletID,
+		TxHash:      info.Hash,
+		MsgHash:     info.MsgHash,
+		NormHash:    info.NormHash,
+		Lt:          info.Lt,
+		TxTime:      time.Unix(info.Timestamp, 0),
+		FromAddress: info.From,
+		ToAddress:   info.To,
+		Amount:      info.Amount,
+		Fee:         info.Fee,
+		Status:      TxStatusConfirmed,
+		Direction:   info.Type,
+		Comment:     info.Comment,
+		Jetton:      info.Jetton,
+		Messages:    info.Messages,
+		NetAmount:   info.NetAmount,
+		Error:       info.Error,
+		Bounced:     info.Bounced,
+		BounceLt:    info.BounceLt,
+		UpdatedAt:   time.Now(),
+	}
+	tx.ComputeExitCode = info.ComputeExitCode
+	tx.ActionResultCode = info.ActionResultCode
+	tx.Aborted = info.Aborted
+	if !info.Success {
+		tx.Status = TxStatusFailed
+	}
+
+	return tx
+}
+
+// upsertTransaction сохраняет транзакцию по tx_hash. Отправка сервиса уже
+// записана как pending по хешу внешнего сообщения - тогда обновляется она,
+// а смена статуса порождает событие send.confirmed или send.failed.
+// Итоговый статус (confirmed, failed, bounced) не перезаписывается: событие
+// об отправке публикуется один раз.
+func (s *WalletService) upsertTransaction(ctx context.Context, db bun.IDB, tx *model.Transaction) error {
+	if tx.MsgHash != "" {
+		existing := new(model.Transaction)
+		err := db.NewSelect().
+			Model(existing).
+			Where("msg_hash = ?", tx.MsgHash).
+			Where("wallet_id = ?", tx.WalletID).
+			For("UPDATE").
+			Scan(ctx)
+		if err == nil {
+			tx.ID = existing.ID
+			if existing.Status != TxStatusPending {
+				if existing.Status != tx.Status && existing.Status != TxStatusBounced {
+					log.Printf("Send %d is %s, indexed transaction %s is %s: status kept", existing.ID, existing.Status, tx.TxHash, tx.Status)
+				}
+				tx.Status = existing.Status
+			}
+			columns := []string{"tx_hash", "lt", "tx_time", "amount", "fee", "status", "direction", "jetton", "messages", "net_amount", "error",
+				"compute_exit_code", "action_result_code", "aborted", "bounced", "bounce_lt", "norm_hash", "updated_at"}
+			// У сообщений, подписанных вне сервиса, получатель известен только из транзакции
+			if existing.ToAddress == "" {
+				columns = append(columns, "to_address", "comment")
+			}
+			_, err = db.NewUpdate().
+				Model(tx).
+				Column(columns...).
+				WherePK().
+				Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to update transaction: %w", err)
+			}
+
+			if existing.Status == tx.Status {
+				return nil
+			}
+
+			// Адреса и комментарий в событии - как в запросе на отправку
+			tx.FromAddress = existing.FromAddress
+			if existing.ToAddress != "" {
+				tx.ToAddress = existing.ToAddress
+				tx.Comment = existing.Comment
+			}
+			return s.emitSendResult(ctx, db, tx)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get transaction: %w", err)
+		}
+	}
+
+	_, err := db.NewInsert().
+		Model(tx).
+		On("CONFLICT (tx_hash) DO UPDATE").
+		Set("status = CASE WHEN t.status = ? THEN EXCLUDED.status ELSE t.status END", TxStatusPending).
+		Set("error = EXCLUDED.error").
+		Set("compute_exit_code = EXCLUDED.compute_exit_code").
+		Set("action_result_code = EXCLUDED.action_result_code").
+		Set("aborted = EXCLUDED.aborted").
+		Set("bounced = EXCLUDED.bounced").
+		Set("bounce_lt = EXCLUDED.bounce_lt").
+		Set("fee = EXCLUDED.fee").
+		Set("amount = EXCLUDED.amount").
+		Set("comment = EXCLUDED.comment").
+		Set("direction = EXCLUDED.direction").
+		Set("jetton = EXCLUDED.jetton").
+		Set("messages = EXCLUDED.messages").
+		Set("net_amount = EXCLUDED.net_amount").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to save transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RunIndexer периодически вызывает IndexWallets до отмены ctx
+func (s *WalletService) RunIndexer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			indexed, err := s.IndexWallets(ctx)
+			if err != nil {
+				log.Printf("Transaction indexing finished with errors: %v", err)
+			}
+			if indexed > 0 {
+				log.Printf("Transactions indexed: %d", indexed)
+			}
+		}
+	}
+}
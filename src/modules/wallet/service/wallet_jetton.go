@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"wallet_test/src/modules/wallet/model"
+)
+
+func (s *WalletService) GetJettonInfo(ctx context.Context, master, network string) (*JettonInfo, error) {
+	info, err := s.tonService.GetJettonInfo(ctx, master, network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jetton info: %w", err)
+	}
+
+	return info, nil
+}
+
+func (s *WalletService) GetJettonBalances(ctx context.Context, walletID int64, masters []string) ([]*JettonBalance, error) {
+	wallet, err := s.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := s.decryptSeed(wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, err := s.tonService.GetJettonBalances(ctx, wallet, seed, masters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jetton balances: %w", err)
+	}
+
+	return balances, nil
+}
+
+// SendJetton отправляет transfer на jetton-кошелек через очередь отправок
+// кошелька, не дожидаясь транзакции. Как и в SendCoins, при ошибке отправки
+// результат с pending записью возвращается вместе с ошибкой.
+func (s *WalletService) SendJetton(ctx context.Context, walletID int64, transfer *JettonTransfer) (*JettonTransferResult, error) {
+	wallet, err := s.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallet.IsWatchOnly {
+		return nil, fmt.Errorf("%w: %s", ErrWatchOnlyWallet, wallet.Address)
+	}
+
+	seed, err := s.decryptSeed(wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, result, err := s.tonService.JettonTransferMessage(ctx, wallet, seed, transfer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send jetton transfer: %w", err)
+	}
+
+	result.Send = &model.Transaction{
+		WalletID:    wallet.ID,
+		FromAddress: wallet.Address,
+		ToAddress:   result.JettonWallet,
+		Amount:      msg.InternalMessage.Amount.String(),
+		Status:      TxStatusPending,
+		Direction:   "out",
+	}
+
+	prepared, err := s.submitSend(ctx, wallet, seed, result.Send, msg)
+	if prepared == nil {
+		return nil, fmt.Errorf("failed to send jetton transfer: %w", err)
+	}
+
+	return result, err
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/xssnick/tonutils-go/address"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// Источники найденной транзакции
+const (
+	TxSourceLocal = "local" // таблица transactions
+	TxSourceChain = "chain" // блокчейн
+)
+
+var ErrInvalidTxHash = errors.New("invalid hash: expected 32 bytes in hex or base64")
+
+// TransactionQuery - поиск транзакции по хешу. Address и Network нужны,
+// если транзакции нет в таблице transactions.
+type TransactionQuery struct {
+	Hash    string // хеш транзакции или внешнего сообщения, hex или base64
+	Address string // аккаунт транзакции
+	Lt      uint64 // lt транзакции, если известен
+	Network string
+}
+
+// TransactionLookup - найденная транзакция
+type TransactionLookup struct {
+	Transaction *model.Transaction // строка таблицы или транзакция, разобранная из блокчейна
+	Account     string             // адрес аккаунта транзакции
+	Network     string
+	Fees        *TxFees // nil у отправки без транзакции и если блокчейн недоступен
+	Source      string  // local или chain
+}
+
+// LookupTransaction ищет транзакцию по хешу транзакции, хешу тела внешнего
+// сообщения или его нормализованному хешу (TEP-467): сначала в таблице
+// transactions, затем в блокчейне по аккаунту из запроса. Разбивка
+// комиссий читается из блокчейна.
+func (s *WalletService) LookupTransaction(ctx context.Context, query *TransactionQuery) (*TransactionLookup, error) {
+	hash, err := parseTxHash(query.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := new(model.Transaction)
+	err = s.db.NewSelect().
+		Model(tx).
+		Relation("Wallet").
+		Where("t.tx_hash = ? OR t.msg_hash = ? OR t.norm_hash = ?", base64.StdEncoding.EncodeToString(hash), hex.EncodeToString(hash), hex.EncodeToString(hash)).
+		Limit(1).
+		Scan(ctx)
+	if err == nil {
+		return s.localTransaction(ctx, tx), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	if query.Address == "" {
+		return nil, fmt.Errorf("%w: not indexed, pass the account address to search the chain", ErrTransactionNotFound)
+	}
+
+	addr, err := address.ParseAddr(query.Address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+	}
+
+	found, err := s.tonService.LookupTransaction(ctx, query.Network, addr, query.Lt, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	var walletID int64
+	wallet, err := s.managedWallet(ctx, addr, query.Network)
+	if err != nil {
+		return nil, err
+	}
+	if wallet != nil {
+		walletID = wallet.ID
+
+		// Транзакция кошелька сервиса, еще не попавшая в индекс
+		infos := []*TransactionInfo{found.Info}
+		if HasEncryptedComments(infos) && !wallet.IsWatchOnly {
+			seed, err := s.decryptSeed(wallet)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.tonService.DecryptComments(ctx, wallet, seed, infos); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &TransactionLookup{
+		Transaction: indexedTransaction(walletID, found.Info),
+		Account:     addr.String(),
+		Network:     query.Network,
+		Fees:        found.Fees,
+		Source:      TxSourceChain,
+	}, nil
+}
+
+// localTransaction дополняет строку таблицы разбивкой комиссий из блокчейна.
+// Ошибка чтения блокчейна не мешает вернуть строку.
+func (s *WalletService) localTransaction(ctx context.Context, tx *model.Transaction) *TransactionLookup {
+	lookup := &TransactionLookup{
+		Transaction: tx,
+		Account:     tx.Wallet.Address,
+		Network:     tx.Wallet.Network,
+		Source:      TxSourceLocal,
+	}
+
+	if tx.TxHash == "" {
+		return lookup
+	}
+
+	hash, err := base64.StdEncoding.DecodeString(tx.TxHash)
+	if err != nil {
+		return lookup
+	}
+
+	addr, err := address.ParseAddr(tx.Wallet.Address)
+	if err != nil {
+		return lookup
+	}
+
+	found, err := s.tonService.LookupTransaction(ctx, tx.Wallet.Network, addr, tx.Lt, hash)
+	if err != nil {
+		log.Printf("Fee breakdown for transaction %s is unavailable: %v", tx.TxHash, err)
+		return lookup
+	}
+	lookup.Fees = found.Fees
+
+	return lookup
+}
+
+// parseTxHash разбирает 32-байтный хеш в hex, base64 или base64url
+func parseTxHash(hash string) ([]byte, error) {
+	hash = strings.TrimSpace(hash)
+
+	if len(hash) == 64 {
+		if data, err := hex.DecodeString(hash); err == nil {
+			return data, nil
+		}
+	}
+
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if data, err := enc.DecodeString(hash); err == nil && len(data) == 32 {
+			return data, nil
+		}
+	}
+
+	return nil, ErrInvalidTxHash
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"wallet_test/src/modules/wallet/model"
+)
+
+func (s *WalletService) ListNFTs(ctx context.Context, walletID int64, scanLimit int, items []string) (*NFTList, error) {
+	wallet, err := s.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := s.decryptSeed(wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	nfts, err := s.tonService.ListNFTs(ctx, wallet, seed, scanLimit, items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nfts: %w", err)
+	}
+
+	return nfts, nil
+}
+
+func (s *WalletService) GetNFTItem(ctx context.Context, walletID int64, item string) (*NFTItemInfo, error) {
+	wallet, err := s.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := s.decryptSeed(wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.tonService.GetNFTItem(ctx, wallet, seed, item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nft item: %w", err)
+	}
+
+	return info, nil
+}
+
+// TransferNFT отправляет transfer на NFT item через очередь отправок
+// кошелька, не дожидаясь транзакции. Как и в SendCoins, при ошибке отправки
+// результат с pending записью возвращается вместе с ошибкой.
+func (s *WalletService) TransferNFT(ctx context.Context, walletID int64, transfer *NFTTransfer) (*NFTTransferResult, error) {
+	wallet, err := s.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallet.IsWatchOnly {
+		return nil, fmt.Errorf("%w: %s", ErrWatchOnlyWallet, wallet.Address)
+	}
+
+	seed, err := s.decryptSeed(wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, result, err := s.tonService.NFTTransferMessage(ctx, wallet, seed, transfer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transfer nft: %w", err)
+	}
+
+	result.Send = &model.Transaction{
+		WalletID:    wallet.ID,
+		FromAddress: wallet.Address,
+		ToAddress:   result.Item,
+		Amount:      result.Amount,
+		Status:      TxStatusPending,
+		Direction:   "out",
+	}
+
+	prepared, err := s.submitSend(ctx, wallet, seed, result.Send, msg)
+	if prepared == nil {
+		return nil, fmt.Errorf("failed to transfer nft: %w", err)
+	}
+
+	return result, err
+}
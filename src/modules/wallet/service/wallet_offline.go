@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"wallet_test/src/modules/wallet/model"
+)
+
+var ErrNotColdWallet = errors.New("offline signing is available only for cold wallets")
+
+// RegisterColdWallet регистрирует холодный кошелек по публичному ключу.
+// Seed фраза хранится только на офлайн машине: сервер готовит переводы,
+// которые подписывает утилита signer, и отправляет подписанный результат.
+// rawAddr необязателен и проверяется на совпадение с адресом по ключу.
+func (s *WalletService) RegisterColdWallet(ctx context.Context, userID int64, publicKey, rawAddr, walletType, network string) (*model.Wallet, error) {
+	if !s.tonService.HasNetwork(network) {
+		return nil, fmt.Errorf("%w: %s", ErrNetworkNotConnected, network)
+	}
+
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := ColdWalletAddress(key, walletType, network)
+	if err != nil {
+		return nil, err
+	}
+
+	if rawAddr != "" {
+		given, err := address.ParseAddr(rawAddr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+		}
+		if !given.Equals(addr) {
+			return nil, fmt.Errorf("%w: %s does not match public key, expected %s", ErrInvalidAddress, rawAddr, addr.String())
+		}
+	}
+
+	// Сервер не может подписывать переводы, поэтому кошелек и watch-only:
+	// все операции с seed фразой для него запрещены
+	wallet := &model.Wallet{
+		UserID:      userID,
+		Address:     storedAddress(addr),
+		PublicKey:   hex.EncodeToString(key),
+		WalletType:  walletType,
+		Network:     network,
+		IsWatchOnly: true,
+		IsCold:      true,
+		IsActive:    true,
+	}
+
+	if err := s.insertWallet(ctx, wallet); err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// PrepareOfflineTransfer готовит неподписанный перевод холодного кошелька.
+// ttl == 0 - DefaultOfflineTTL.
+func (s *WalletService) PrepareOfflineTransfer(ctx context.Context, walletID int64, transfers []*TONTransfer, ttl time.Duration) (*OfflineTransfer, error) {
+	wallet, err := s.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !wallet.IsCold {
+		return nil, fmt.Errorf("%w: %s", ErrNotColdWallet, wallet.Address)
+	}
+
+	if ttl == 0 {
+		ttl = DefaultOfflineTTL
+	}
+	if ttl > MaxOfflineTTL {
+		return nil, fmt.Errorf("%w: ttl exceeds %s", ErrInvalidTransfer, MaxOfflineTTL)
+	}
+
+	// Перевод подписывается seqno, следующим за pending отправками кошелька:
+	// с seqno контракта он бы конфликтовал с еще не принятым сообщением
+	minSeqno, err := s.nextSendSeqno(ctx, s.db, wallet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err := s.tonService.PrepareOfflineTransfer(ctx, wallet, transfers, minSeqno, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare offline transfer: %w", err)
+	}
+
+	return transfer, nil
+}
+
+// BroadcastOfflineTransfer отправляет подписанный перевод холодного кошелька.
+// Сообщение должно быть адресовано этому кошельку; подпись, срок действия
+// и запись pending отправки проверяет BroadcastMessage.
+func (s *WalletService) BroadcastOfflineTransfer(ctx context.Context, walletID int64, boc string) (*BroadcastResult, error) {
+	wallet, err := s.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !wallet.IsCold {
+		return nil, fmt.Errorf("%w: %s", ErrNotColdWallet, wallet.Address)
+	}
+
+	msg, err := s.tonService.ParseExternalMessage(boc, wallet.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := address.ParseAddr(wallet.Address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stored wallet address: %w", err)
+	}
+	if !msg.Destination.Equals(addr) {
+		return nil, fmt.Errorf("%w: message is addressed to %s, not to wallet %d", ErrInvalidExternalMessage, msg.Destination.String(), walletID)
+	}
+
+	return s.BroadcastMessage(ctx, boc, wallet.Network)
+}
@@ -0,0 +1,393 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// Статусы отправок в таблице transactions
+const (
+	TxStatusPending   = "pending"
+	TxStatusConfirmed = "confirmed"
+	TxStatusFailed    = "failed"
+	TxStatusBounced   = "bounced" // перевод вернулся bounce сообщением
+)
+
+// sendTrackScanLimit - сколько транзакций кошелька трекер просматривает
+// назад до времени самой старой pending отправки
+const sendTrackScanLimit = 1000
+
+// sendExpiryMargin - запас после valid_until, после которого ненайденная
+// отправка считается неуспешной (задержка блоков и расхождение часов)
+const sendExpiryMargin = time.Minute
+
+// sendPrepareTimeout - сколько отправка может подписывать сообщение,
+// удерживая блокировку кошелька: seqno запрашивается у liteserver, и
+// медленный liteserver не должен останавливать очередь отправок кошелька
+const sendPrepareTimeout = 10 * time.Second
+
+var ErrSendNotFound = errors.New("send not found")
+
+// SendCoins подписывает перевод, сохраняет pending запись и отправляет
+// сообщение, не дожидаясь транзакции. Статус обновляет TrackPendingSends.
+// Если отправка не удалась, запись возвращается вместе с ошибкой: сообщение
+// могло дойти до сети, и трекер повторяет его до истечения valid_until.
+func (s *WalletService) SendCoins(ctx context.Context, walletID int64, transfer *TONTransfer) (*model.Transaction, *PreparedMessage, error) {
+	wallet, err := s.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if wallet.IsWatchOnly {
+		return nil, nil, fmt.Errorf("%w: %s", ErrWatchOnlyWallet, wallet.Address)
+	}
+
+	seed, err := s.decryptSeed(wallet)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	msg, err := s.tonService.TransferMessage(ctx, wallet, seed, transfer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to prepare transaction: %w", err)
+	}
+
+	tx := &model.Transaction{
+		WalletID:    wallet.ID,
+		FromAddress: wallet.Address,
+		ToAddress:   transfer.Recipient,
+		Amount:      transfer.Amount,
+		Status:      TxStatusPending,
+		Direction:   "out",
+		Comment:     transfer.Comment,
+	}
+
+	prepared, err := s.submitSend(ctx, wallet, seed, tx, msg)
+	if prepared == nil {
+		return nil, nil, err
+	}
+
+	prepared.Mode = msg.Mode
+	prepared.Bounce = msg.InternalMessage.Bounce
+
+	return tx, prepared, err
+}
+
+// submitSend подписывает сообщения одним внешним сообщением кошелька,
+// сохраняет pending запись tx и отправляет сообщение, не дожидаясь
+// транзакции. Через нее идут все отправки кошельков сервиса: блокировка
+// строки кошелька выстраивает их в очередь, и каждая получает seqno,
+// следующий за pending отправками. Запись сохраняется до отправки, чтобы
+// трекер нашел транзакцию, даже если ответ liteserver потеряется.
+// Без PreparedMessage отправка не записана; ошибка вместе с PreparedMessage
+// значит, что запись осталась pending и трекер повторяет сообщение.
+func (s *WalletService) submitSend(ctx context.Context, stored *model.Wallet, seed *Seed, tx *model.Transaction, messages ...*wallet.Message) (*PreparedMessage, error) {
+	var prepared *PreparedMessage
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, db bun.Tx) error {
+		// У highload v3 нет seqno: query_id выдается атомарно, без очереди
+		var minSeqno int64
+		if stored.WalletType != WalletTypeHighloadV3 {
+			_, err := db.NewSelect().
+				Model((*model.Wallet)(nil)).
+				Column("id").
+				Where("id = ?", stored.ID).
+				For("UPDATE").
+				Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to lock wallet: %w", err)
+			}
+
+			minSeqno, err = s.nextSendSeqno(ctx, db, stored.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Шифрование комментария и остальные запросы к сети выполняются
+		// до очереди; здесь остаются только seqno (query_id) и подпись
+		prepareCtx, cancel := context.WithTimeout(ctx, sendPrepareTimeout)
+		defer cancel()
+
+		var err error
+		prepared, err = s.tonService.prepareMessages(prepareCtx, stored, seed, minSeqno, messages...)
+		if err != nil {
+			return fmt.Errorf("failed to prepare transaction: %w", err)
+		}
+
+		tx.MsgHash = prepared.MsgHash
+		tx.NormHash = prepared.NormHash
+		tx.FromAddress = prepared.Address
+		tx.ExpiresAt = prepared.ExpiresAt
+		tx.Seqno = prepared.Seqno
+		tx.Boc = prepared.Boc
+
+		if _, err := db.NewInsert().Model(tx).Exec(ctx); err != nil {
+			return fmt.Errorf("failed to save transaction: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tonService.Broadcast(ctx, prepared); err != nil {
+		// Сообщение могло дойти до сети: запись остается pending,
+		// и до истечения valid_until ее статус уточнит трекер
+		tx.Error = err.Error()
+		if uerr := s.updateSend(ctx, tx); uerr != nil {
+			err = errors.Join(err, uerr)
+		}
+		return prepared, err
+	}
+
+	return prepared, nil
+}
+
+// nextSendSeqno возвращает seqno, следующий за занятыми pending отправками
+// кошелька, срок которых не истек (0, если таких нет)
+func (s *WalletService) nextSendSeqno(ctx context.Context, db bun.IDB, walletID int64) (int64, error) {
+	var seqno sql.NullInt64
+	err := db.NewSelect().
+		Model((*model.Transaction)(nil)).
+		ColumnExpr("MAX(seqno)").
+		Where("wallet_id = ?", walletID).
+		Where("status = ?", TxStatusPending).
+		Where("seqno IS NOT NULL").
+		Where("expires_at > ?", time.Now()).
+		Scan(ctx, &seqno)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending seqno: %w", err)
+	}
+
+	if !seqno.Valid {
+		return 0, nil
+	}
+	return seqno.Int64 + 1, nil
+}
+
+// GetSend возвращает отправку кошелька по ID
+func (s *WalletService) GetSend(ctx context.Context, walletID, sendID int64) (*model.Transaction, error) {
+	tx := new(model.Transaction)
+	err := s.db.NewSelect().
+		Model(tx).
+		Where("id = ?", sendID).
+		Where("wallet_id = ?", walletID).
+		Where("msg_hash IS NOT NULL").
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrSendNotFound, sendID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get send: %w", err)
+	}
+
+	return tx, nil
+}
+
+// TrackPendingSends ищет транзакции pending отправок в истории кошельков
+// назад до времени самой старой отправки. Найденные получают статус confirmed
+// или failed, ненайденные после истечения valid_until - failed; до истечения
+// сообщение отправляется повторно (следующее по seqno liteserver примет,
+// только когда обработано предыдущее). Если история до отправки не
+// просмотрена целиком, ненайденные отправки оставляются индексатору.
+func (s *WalletService) TrackPendingSends(ctx context.Context) (int, error) {
+	var pending []*model.Transaction
+	err := s.db.NewSelect().
+		Model(&pending).
+		Relation("Wallet").
+		Where("t.status = ?", TxStatusPending).
+		Where("t.msg_hash IS NOT NULL").
+		Order("t.id").
+		Scan(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending sends: %w", err)
+	}
+
+	byWallet := map[int64][]*model.Transaction{}
+	for _, tx := range pending {
+		byWallet[tx.WalletID] = append(byWallet[tx.WalletID], tx)
+	}
+
+	var errs []error
+	resolved := 0
+	for walletID, sends := range byWallet {
+		hashes := make([]string, len(sends))
+		since := sends[0].CreatedAt
+		for i, tx := range sends {
+			hashes[i] = tx.MsgHash
+			if tx.CreatedAt.Before(since) {
+				since = tx.CreatedAt
+			}
+		}
+
+		// Проверяем истечение до запроса истории: иначе транзакция,
+		// попавшая в блок между запросом и проверкой, была бы пропущена
+		now := time.Now()
+
+		found, complete, err := s.tonService.FindSentTransactions(ctx, sends[0].Wallet, hashes, since.Add(-sendExpiryMargin), sendTrackScanLimit)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("wallet %d: %w", walletID, err))
+			continue
+		}
+
+		for _, tx := range sends {
+			sent, ok := found[tx.MsgHash]
+			switch {
+			case ok:
+				tx.TxHash = sent.Hash
+				tx.Lt = sent.Lt
+				tx.TxTime = sent.Time
+				if sent.Amount != "" {
+					tx.Amount = sent.Amount
+				}
+				tx.Fee = sent.Fee
+				tx.Error = sent.Error
+				tx.ComputeExitCode = sent.ComputeExitCode
+				tx.ActionResultCode = sent.ActionResultCode
+				tx.Aborted = sent.Aborted
+				tx.Status = TxStatusConfirmed
+				if !sent.Success {
+					tx.Status = TxStatusFailed
+				}
+			case complete && now.After(tx.ExpiresAt.Add(sendExpiryMargin)):
+				tx.Status = TxStatusFailed
+				tx.Error = "message expired without transaction"
+			default:
+				if tx.Boc != "" && now.Before(tx.ExpiresAt) {
+					// Ошибка ожидаема, если сообщение уже принято или ждет
+					// обработки предыдущего seqno
+					_ = s.tonService.Rebroadcast(ctx, tx.Wallet, tx.Boc)
+				}
+				continue
+			}
+
+			ok, err := s.resolveSend(ctx, tx)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("send %d: %w", tx.ID, err))
+				continue
+			}
+			if ok {
+				resolved++
+			}
+		}
+	}
+
+	return resolved, errors.Join(errs...)
+}
+
+// updateSend сохраняет ошибку отправки, оставляя запись pending
+func (s *WalletService) updateSend(ctx context.Context, tx *model.Transaction) error {
+	tx.UpdatedAt = time.Now()
+	_, err := s.db.NewUpdate().
+		Model(tx).
+		Column("error", "updated_at").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction: %w", err)
+	}
+	return nil
+}
+
+// resolveSend сохраняет итоговый статус pending отправки и событие
+// send.confirmed или send.failed. Возвращает false, если отправку
+// уже обновил индексатор.
+func (s *WalletService) resolveSend(ctx context.Context, tx *model.Transaction) (bool, error) {
+	resolved := false
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, db bun.Tx) error {
+		tx.UpdatedAt = time.Now()
+		res, err := db.NewUpdate().
+			Model(tx).
+			Column("tx_hash", "lt", "tx_time", "amount", "fee", "status", "error",
+				"compute_exit_code", "action_result_code", "aborted", "updated_at").
+			WherePK().
+			Where("status = ?", TxStatusPending).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to update transaction: %w", err)
+		}
+
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil
+		}
+		resolved = true
+
+		return s.emitSendResult(ctx, db, tx)
+	})
+
+	return resolved, err
+}
+
+// SendEvent - данные событий send.confirmed, send.failed и send.bounced
+type SendEvent struct {
+	SendID    int64  `json:"send_id"`
+	WalletID  int64  `json:"wallet_id"`
+	Address   string `json:"address"`
+	Status    string `json:"status"`
+	MsgHash   string `json:"msg_hash"`
+	TxHash    string `json:"tx_hash,omitempty"`
+	Lt        uint64 `json:"lt,omitempty"`
+	Amount    string `json:"amount"`
+	Fee       string `json:"fee,omitempty"`
+	Recipient string `json:"recipient"`
+	Comment   string `json:"comment,omitempty"`
+	Error     string `json:"error,omitempty"`
+
+	BounceTxHash string `json:"bounce_tx_hash,omitempty"` // транзакция возврата
+}
+
+func (s *WalletService) emitSendResult(ctx context.Context, db bun.IDB, tx *model.Transaction) error {
+	event := EventSendConfirmed
+	switch tx.Status {
+	case TxStatusFailed:
+		event = EventSendFailed
+	case TxStatusBounced:
+		event = EventSendBounced
+	}
+
+	return s.webhooks.Emit(ctx, db, event, &SendEvent{
+		SendID:    tx.ID,
+		WalletID:  tx.WalletID,
+		Address:   tx.FromAddress,
+		Status:    tx.Status,
+		MsgHash:   tx.MsgHash,
+		TxHash:    tx.TxHash,
+		Lt:        tx.Lt,
+		Amount:    tx.Amount,
+		Fee:       tx.Fee,
+		Recipient: tx.ToAddress,
+		Comment:   tx.Comment,
+		Error:     tx.Error,
+
+		BounceTxHash: tx.BounceTxHash,
+	})
+}
+
+// RunSendTracker периодически вызывает TrackPendingSends до отмены ctx
+func (s *WalletService) RunSendTracker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			resolved, err := s.TrackPendingSends(ctx)
+			if err != nil {
+				log.Printf("Send tracking finished with errors: %v", err)
+			}
+			if resolved > 0 {
+				log.Printf("Sends resolved: %d", resolved)
+			}
+		}
+	}
+}
@@ -2,21 +2,38 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/driver/pgdriver"
+	"github.com/xssnick/tonutils-go/address"
 	"wallet_test/src/modules/wallet/model"
 )
 
+// pgUniqueViolation - код ошибки PostgreSQL при нарушении уникальности
+const pgUniqueViolation = "23505"
+
+var (
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+	ErrWalletExists    = errors.New("wallet already exists")
+	ErrWatchOnlyWallet = errors.New("wallet is watch-only and cannot send")
+	ErrUnknownWallet   = errors.New("wallet type is not detected, pass wallet_type")
+
+	ErrSubwalletNotSupported = errors.New("subwallet_id is supported only for HighloadV3 wallets")
+)
+
 type WalletService struct {
 	db            *bun.DB
 	tonService    *TONService
+	webhooks      *WebhookService
 	encryptionKey string
 }
 
-func NewWalletService(db *bun.DB, network string, encryptionKey string) (*WalletService, error) {
-	tonService, err := NewTONService(network)
+func NewWalletService(db *bun.DB, networks []string, encryptionKey string, webhooks *WebhookService) (*WalletService, error) {
+	tonService, err := NewTONService(networks, db)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create TON service: %w", err)
 	}
@@ -24,14 +41,51 @@ func NewWalletService(db *bun.DB, network string, encryptionKey string) (*Wallet
 	return &WalletService{
 		db:            db,
 		tonService:    tonService,
+		webhooks:      webhooks,
 		encryptionKey: encryptionKey,
 	}, nil
 }
 
-func (s *WalletService) CreateWallet(ctx context.Context, userID int64, walletType, network string) (*model.Wallet, error) {
-	seedWords := s.tonService.GenerateWallet()
+func (s *WalletService) CreateWallet(ctx context.Context, userID int64, walletType, network string, subwalletID *uint32) (*model.Wallet, error) {
+	if !s.tonService.HasNetwork(network) {
+		return nil, fmt.Errorf("%w: %s", ErrNetworkNotConnected, network)
+	}
+
+	seed := &Seed{Words: s.tonService.GenerateWallet()}
+
+	return s.saveWallet(ctx, userID, seed, walletType, network, subwalletID)
+}
+
+// ImportWallet добавляет существующий кошелек по 24-словной мнемонике
+func (s *WalletService) ImportWallet(ctx context.Context, userID int64, mnemonic, password, walletType, network string, subwalletID *uint32) (*model.Wallet, error) {
+	if !s.tonService.HasNetwork(network) {
+		return nil, fmt.Errorf("%w: %s", ErrNetworkNotConnected, network)
+	}
+
+	seed := &Seed{
+		Words:    strings.Fields(mnemonic),
+		Password: password,
+	}
+
+	if len(seed.Words) != 24 {
+		return nil, fmt.Errorf("%w: expected 24 words, got %d", ErrInvalidMnemonic, len(seed.Words))
+	}
+
+	// Проверяем слова и контрольную сумму мнемоники (с учетом пароля)
+	if _, err := PublicKeyFromSeed(seed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMnemonic, err)
+	}
+
+	return s.saveWallet(ctx, userID, seed, walletType, network, subwalletID)
+}
+
+// saveWallet вычисляет адрес кошелька, шифрует seed и сохраняет кошелек
+func (s *WalletService) saveWallet(ctx context.Context, userID int64, seed *Seed, walletType, network string, subwalletID *uint32) (*model.Wallet, error) {
+	if subwalletID != nil && walletType != WalletTypeHighloadV3 {
+		return nil, fmt.Errorf("%w: %s", ErrSubwalletNotSupported, walletType)
+	}
 
-	walletInfo, err := s.tonService.CreateWalletFromSeed(seedWords, walletType)
+	walletInfo, err := s.tonService.CreateWalletFromSeed(seed, walletType, network, subwalletID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create TON wallet: %w", err)
 	}
@@ -41,23 +95,282 @@ func (s *WalletService) CreateWallet(ctx context.Context, userID int64, walletTy
 		return nil, fmt.Errorf("failed to encrypt seed: %w", err)
 	}
 
+	var encryptedPassword string
+	if seed.Password != "" {
+		encryptedPassword, err = EncryptSeed(seed.Password, s.encryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt mnemonic password: %w", err)
+		}
+	}
+
 	wallet := &model.Wallet{
-		UserID:        userID,
-		Address:       walletInfo.Address,
-		EncryptedSeed: encryptedSeed,
-		WalletType:    walletType,
-		Network:       network,
-		IsActive:      true,
+		UserID:            userID,
+		Address:           walletInfo.Address,
+		PublicKey:         walletInfo.PublicKey,
+		EncryptedSeed:     encryptedSeed,
+		EncryptedPassword: encryptedPassword,
+		WalletType:        walletType,
+		Network:           network,
+		IsActive:          true,
+	}
+
+	// subwallet_id входит в адрес highload v3 и нужен для подписи
+	if walletType == WalletTypeHighloadV3 {
+		subwallet := int64(walletInfo.SubwalletID)
+		wallet.SubwalletID = &subwallet
 	}
 
-	_, err = s.db.NewInsert().Model(wallet).Exec(ctx)
+	// global_id входит в wallet_id и адрес V5R1
+	if walletType == WalletTypeV5R1Final {
+		wallet.NetworkGlobalID = &walletInfo.NetworkGlobalID
+	}
+
+	if err := s.insertWallet(ctx, wallet); err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// WatchWallet регистрирует watch-only кошелек по адресу, без seed фразы.
+// Такой кошелек доступен только для чтения баланса и истории.
+func (s *WalletService) WatchWallet(ctx context.Context, userID int64, rawAddr, walletType, network string) (*model.Wallet, error) {
+	info, err := s.tonService.ResolveWatchedAddress(ctx, rawAddr, network)
 	if err != nil {
-		return nil, fmt.Errorf("failed to save wallet: %w", err)
+		return nil, err
+	}
+
+	// Тип, определенный по коду контракта, приоритетнее переданного
+	if info.WalletType != "" {
+		walletType = info.WalletType
+	}
+	if walletType == "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownWallet, info.Address)
+	}
+
+	wallet := &model.Wallet{
+		UserID:      userID,
+		Address:     info.Address,
+		PublicKey:   info.PublicKey,
+		WalletType:  walletType,
+		Network:     network,
+		IsWatchOnly: true,
+		IsActive:    true,
+	}
+
+	if err := s.insertWallet(ctx, wallet); err != nil {
+		return nil, err
 	}
 
 	return wallet, nil
 }
 
+func (s *WalletService) insertWallet(ctx context.Context, wallet *model.Wallet) error {
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().Model(wallet).Exec(ctx)
+		if err != nil {
+			var pgErr pgdriver.Error
+			if errors.As(err, &pgErr) && pgErr.Field('C') == pgUniqueViolation {
+				return fmt.Errorf("%w: %s", ErrWalletExists, wallet.Address)
+			}
+			return fmt.Errorf("failed to save wallet: %w", err)
+		}
+
+		return s.webhooks.Emit(ctx, tx, EventWalletCreated, walletEvent(wallet))
+	})
+}
+
+// WalletEvent - данные событий wallet.created и wallet.deleted
+type WalletEvent struct {
+	WalletID    int64  `json:"wallet_id"`
+	UserID      int64  `json:"user_id"`
+	Address     string `json:"address"`
+	WalletType  string `json:"wallet_type"`
+	Network     string `json:"network"`
+	IsWatchOnly bool   `json:"is_watch_only"`
+	IsCold      bool   `json:"is_cold"`
+}
+
+func walletEvent(wallet *model.Wallet) *WalletEvent {
+	return &WalletEvent{
+		WalletID:    wallet.ID,
+		UserID:      wallet.UserID,
+		Address:     wallet.Address,
+		WalletType:  wallet.WalletType,
+		Network:     wallet.Network,
+		IsWatchOnly: wallet.IsWatchOnly,
+		IsCold:      wallet.IsCold,
+	}
+}
+
+// decryptSeed расшифровывает мнемонику кошелька и пароль к ней.
+// Для watch-only и холодного кошелька возвращает nil: чтение идет по
+// сохраненному адресу, а ключ холодного кошелька есть только у signer.
+func (s *WalletService) decryptSeed(wallet *model.Wallet) (*Seed, error) {
+	if wallet.IsWatchOnly || wallet.IsCold {
+		return nil, nil
+	}
+
+	seedPhrase, err := DecryptSeed(wallet.EncryptedSeed, s.encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt seed: %w", err)
+	}
+
+	seed := &Seed{Words: strings.Split(seedPhrase, " ")}
+
+	if wallet.EncryptedPassword != "" {
+		seed.Password, err = DecryptSeed(wallet.EncryptedPassword, s.encryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt mnemonic password: %w", err)
+		}
+	}
+
+	return seed, nil
+}
+
+// BackfillPublicKeys заполняет public_key у кошельков, сохраненных без него,
+// расшифровывая их seed фразы. Возвращает количество обновленных кошельков.
+func (s *WalletService) BackfillPublicKeys(ctx context.Context) (int, error) {
+	var wallets []*model.Wallet
+	err := s.db.NewSelect().
+		Model(&wallets).
+		Where("public_key = ''").
+		Where("is_watch_only = ?", false).
+		Scan(ctx)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to get wallets: %w", err)
+	}
+
+	var errs []error
+	updated := 0
+	for _, wallet := range wallets {
+		seed, err := s.decryptSeed(wallet)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("wallet %d: %w", wallet.ID, err))
+			continue
+		}
+
+		publicKey, err := PublicKeyFromSeed(seed)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("wallet %d: %w", wallet.ID, err))
+			continue
+		}
+
+		_, err = s.db.NewUpdate().
+			Model((*model.Wallet)(nil)).
+			Set("public_key = ?", publicKey).
+			Set("updated_at = current_timestamp").
+			Where("id = ?", wallet.ID).
+			Exec(ctx)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("wallet %d: failed to save public key: %w", wallet.ID, err))
+			continue
+		}
+		updated++
+	}
+
+	return updated, errors.Join(errs...)
+}
+
+// BackfillNetworkGlobalIDs сохраняет global_id у V5R1 кошельков, созданных
+// до его сохранения. Адрес testnet кошельков того времени выведен с
+// MainnetGlobalID, поэтому global_id подбирается по сохраненному адресу.
+func (s *WalletService) BackfillNetworkGlobalIDs(ctx context.Context) (int, error) {
+	var wallets []*model.Wallet
+	err := s.db.NewSelect().
+		Model(&wallets).
+		Where("wallet_type = ?", WalletTypeV5R1Final).
+		Where("network_global_id IS NULL").
+		Where("is_watch_only = ?", false).
+		Scan(ctx)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to get wallets: %w", err)
+	}
+
+	var errs []error
+	updated := 0
+	for _, wallet := range wallets {
+		if !s.tonService.HasNetwork(wallet.Network) {
+			continue
+		}
+
+		seed, err := s.decryptSeed(wallet)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("wallet %d: %w", wallet.ID, err))
+			continue
+		}
+
+		globalID, err := s.tonService.DetectNetworkGlobalID(wallet, seed)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("wallet %d: %w", wallet.ID, err))
+			continue
+		}
+
+		_, err = s.db.NewUpdate().
+			Model((*model.Wallet)(nil)).
+			Set("network_global_id = ?", globalID).
+			Set("updated_at = current_timestamp").
+			Where("id = ?", wallet.ID).
+			Exec(ctx)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("wallet %d: failed to save network global id: %w", wallet.ID, err))
+			continue
+		}
+		updated++
+	}
+
+	return updated, errors.Join(errs...)
+}
+
+// BackfillStoredAddresses приводит адреса кошельков к форме storedAddress.
+// Watch-only кошельки раньше сохранялись в non-bounceable форме; строка,
+// повторяющая уже сохраненный кошелек, остается как есть и попадает в ошибки.
+func (s *WalletService) BackfillStoredAddresses(ctx context.Context) (int, error) {
+	var wallets []*model.Wallet
+	err := s.db.NewSelect().
+		Model(&wallets).
+		Column("id", "address").
+		Scan(ctx)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to get wallets: %w", err)
+	}
+
+	var errs []error
+	updated := 0
+	for _, wallet := range wallets {
+		addr, err := address.ParseAddr(wallet.Address)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("wallet %d: %w", wallet.ID, err))
+			continue
+		}
+
+		stored := storedAddress(addr)
+		if stored == wallet.Address {
+			continue
+		}
+
+		_, err = s.db.NewUpdate().
+			Model((*model.Wallet)(nil)).
+			Set("address = ?", stored).
+			Set("updated_at = current_timestamp").
+			Where("id = ?", wallet.ID).
+			Exec(ctx)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("wallet %d: failed to save address %s: %w", wallet.ID, stored, err))
+			continue
+		}
+		updated++
+	}
+
+	return updated, errors.Join(errs...)
+}
+
 func (s *WalletService) GetWalletByID(ctx context.Context, walletID int64) (*model.Wallet, error) {
 	wallet := &model.Wallet{}
 	err := s.db.NewSelect().
@@ -108,14 +421,12 @@ func (s *WalletService) GetWalletInfo(ctx context.Context, walletID int64) (*Wal
 		return nil, err
 	}
 
-	seedPhrase, err := DecryptSeed(wallet.EncryptedSeed, s.encryptionKey)
+	seed, err := s.decryptSeed(wallet)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt seed: %w", err)
+		return nil, err
 	}
 
-	seedWords := strings.Split(seedPhrase, " ")
-
-	info, err := s.tonService.GetWalletInfo(ctx, seedWords, wallet.WalletType)
+	info, err := s.tonService.GetWalletInfo(ctx, wallet, seed)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get wallet info from blockchain: %w", err)
 	}
@@ -129,14 +440,12 @@ func (s *WalletService) GetBalance(ctx context.Context, walletID int64) (string,
 		return "", err
 	}
 
-	seedPhrase, err := DecryptSeed(wallet.EncryptedSeed, s.encryptionKey)
+	seed, err := s.decryptSeed(wallet)
 	if err != nil {
-		return "", fmt.Errorf("failed to decrypt seed: %w", err)
+		return "", err
 	}
 
-	seedWords := strings.Split(seedPhrase, " ")
-
-	balance, err := s.tonService.GetBalance(ctx, seedWords, wallet.WalletType)
+	balance, err := s.tonService.GetBalance(ctx, wallet, seed)
 	if err != nil {
 		return "", fmt.Errorf("failed to get balance: %w", err)
 	}
@@ -145,57 +454,48 @@ func (s *WalletService) GetBalance(ctx context.Context, walletID int64) (string,
 }
 
 func (s *WalletService) DeleteWallet(ctx context.Context, walletID int64) error {
-	_, err := s.db.NewUpdate().
-		Model((*model.Wallet)(nil)).
-		Set("is_active = ?", false).
-		Where("id = ?", walletID).
-		Exec(ctx)
-
-	if err != nil {
-		return fmt.Errorf("failed to delete wallet: %w", err)
-	}
-
-	return nil
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		wallet := new(model.Wallet)
+		err := tx.NewUpdate().
+			Model(wallet).
+			Set("is_active = ?", false).
+			Where("id = ?", walletID).
+			Where("is_active = ?", true).
+			Returning("*").
+			Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Кошелек уже удален: событие не повторяем
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete wallet: %w", err)
+		}
+
+		return s.webhooks.Emit(ctx, tx, EventWalletDeleted, walletEvent(wallet))
+	})
 }
 
-func (s *WalletService) GetTransactions(ctx context.Context, walletID int64, limit int) ([]*TransactionInfo, error) {
+// EstimateSend оценивает комиссии перевода без отправки
+func (s *WalletService) EstimateSend(ctx context.Context, walletID int64, transfer *TONTransfer) (*FeeEstimate, error) {
 	wallet, err := s.GetWalletByID(ctx, walletID)
 	if err != nil {
 		return nil, err
 	}
 
-	seedPhrase, err := DecryptSeed(wallet.EncryptedSeed, s.encryptionKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt seed: %w", err)
+	// Для оценки нужно подписанное внешнее сообщение, как при отправке
+	if wallet.IsWatchOnly {
+		return nil, fmt.Errorf("%w: %s", ErrWatchOnlyWallet, wallet.Address)
 	}
 
-	seedWords := strings.Split(seedPhrase, " ")
-
-	transactions, err := s.tonService.GetTransactions(ctx, seedWords, wallet.WalletType, limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get transactions: %w", err)
-	}
-
-	return transactions, nil
-}
-
-func (s *WalletService) SendCoins(ctx context.Context, walletID int64, recipient, amount, comment string) (*SendTransactionResult, error) {
-	wallet, err := s.GetWalletByID(ctx, walletID)
+	seed, err := s.decryptSeed(wallet)
 	if err != nil {
 		return nil, err
 	}
 
-	seedPhrase, err := DecryptSeed(wallet.EncryptedSeed, s.encryptionKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt seed: %w", err)
-	}
-
-	seedWords := strings.Split(seedPhrase, " ")
-
-	result, err := s.tonService.SendTransaction(ctx, seedWords, wallet.WalletType, recipient, amount, comment)
+	estimate, err := s.tonService.EstimateTransfer(ctx, wallet, seed, transfer)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send transaction: %w", err)
+		return nil, fmt.Errorf("failed to estimate transfer: %w", err)
 	}
 
-	return result, nil
+	return estimate, nil
 }
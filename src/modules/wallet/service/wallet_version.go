@@ -0,0 +1,88 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+)
+
+// Типы кошельков, которые хранятся в model.Wallet.WalletType
+const (
+	WalletTypeV5R1Final  = "V5R1Final"
+	WalletTypeV4R2       = "V4R2"
+	WalletTypeV3R2       = "V3R2"
+	WalletTypeHighloadV3 = "HighloadV3"
+)
+
+// highloadV3MessageTTL - время жизни внешнего сообщения highload v3 (секунды).
+// Входит в state-init, поэтому влияет на адрес кошелька и не должно меняться.
+const highloadV3MessageTTL = 60 * 5
+
+// walletVersions - реестр поддерживаемых версий кошельков:
+// тип из БД -> конфиг tonutils-go для указанного NetworkGlobalID
+var walletVersions = map[string]func(networkGlobalID int32) wallet.VersionConfig{
+	WalletTypeV5R1Final: func(networkGlobalID int32) wallet.VersionConfig {
+		return wallet.ConfigV5R1Final{
+			NetworkGlobalID: networkGlobalID,
+		}
+	},
+	WalletTypeV4R2: func(int32) wallet.VersionConfig {
+		return wallet.V4R2
+	},
+	WalletTypeV3R2: func(int32) wallet.VersionConfig {
+		return wallet.V3R2
+	},
+	WalletTypeHighloadV3: func(int32) wallet.VersionConfig {
+		return wallet.ConfigHighloadV3{
+			MessageTTL: highloadV3MessageTTL,
+		}
+	},
+}
+
+// WalletVersionConfig возвращает конфиг tonutils-go для типа кошелька
+func WalletVersionConfig(walletType string, networkGlobalID int32) (wallet.VersionConfig, error) {
+	build, ok := walletVersions[walletType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported wallet type: %s", walletType)
+	}
+
+	return build(networkGlobalID), nil
+}
+
+// walletHasSeqno - есть ли у контракта кошелька get-метод seqno
+func walletHasSeqno(walletType string) bool {
+	switch walletType {
+	case WalletTypeV5R1Final, WalletTypeV4R2, WalletTypeV3R2:
+		return true
+	}
+	return false
+}
+
+// walletMaxMessages - сколько внутренних сообщений контракт кошелька
+// принимает в одном внешнем сообщении
+func walletMaxMessages(walletType string) int {
+	switch walletType {
+	case WalletTypeV5R1Final:
+		return 255
+	case WalletTypeHighloadV3:
+		return 254 * 254
+	}
+	return 4
+}
+
+// DetectWalletType определяет тип кошелька по коду контракта.
+// Возвращает пустую строку, если версия не входит в реестр.
+func DetectWalletType(acc *tlb.Account) string {
+	switch wallet.GetWalletVersion(acc) {
+	case wallet.V5R1Final:
+		return WalletTypeV5R1Final
+	case wallet.V4R2:
+		return WalletTypeV4R2
+	case wallet.V3R2:
+		return WalletTypeV3R2
+	case wallet.HighloadV3:
+		return WalletTypeHighloadV3
+	}
+	return ""
+}
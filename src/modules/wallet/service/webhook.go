@@ -0,0 +1,573 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/uptrace/bun"
+	"wallet_test/src/modules/wallet/model"
+)
+
+// События, отправляемые на webhook
+const (
+	EventDepositReceived = "deposit.received"
+	EventSendConfirmed   = "send.confirmed"
+	EventSendFailed      = "send.failed"
+	EventSendBounced     = "send.bounced"
+	EventWalletCreated   = "wallet.created"
+	EventWalletDeleted   = "wallet.deleted"
+)
+
+// WebhookEvents - все события, на которые можно подписаться
+var WebhookEvents = []string{
+	EventDepositReceived,
+	EventSendConfirmed,
+	EventSendFailed,
+	EventSendBounced,
+	EventWalletCreated,
+	EventWalletDeleted,
+}
+
+// Статусы доставки
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Заголовки запроса на webhook. Подпись - hex(HMAC-SHA256(secret, timestamp + "." + body)),
+// timestamp входит в подпись, чтобы получатель мог отбрасывать старые запросы.
+const (
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderDelivery  = "X-Webhook-Delivery"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	// webhookMaxAttempts - после стольких неудачных попыток доставка получает статус failed
+	webhookMaxAttempts = 10
+	// webhookRetryBase - задержка перед второй попыткой, дальше удваивается
+	webhookRetryBase = 30 * time.Second
+	// webhookRetryMax - верхняя граница задержки между попытками
+	webhookRetryMax = 6 * time.Hour
+	// webhookDispatchBatch - сколько доставок обрабатывается за один проход
+	webhookDispatchBatch = 100
+	// webhookDispatchWorkers - сколько запросов на webhook выполняется одновременно
+	webhookDispatchWorkers = 10
+	// webhookClaimTTL - на сколько откладывается захваченная доставка: пока идет
+	// попытка, другие экземпляры ее не берут, а после сбоя процесса она повторится
+	webhookClaimTTL = time.Minute
+	// webhookRequestTimeout - таймаут одного запроса на webhook
+	webhookRequestTimeout = 10 * time.Second
+	// webhookSecretSize - длина сгенерированного секрета в байтах
+	webhookSecretSize = 32
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhook          = errors.New("invalid webhook subscription")
+)
+
+// WebhookEvent - тело запроса на webhook
+type WebhookEvent struct {
+	ID        string    `json:"id"` // одинаковый при повторных попытках и replay
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// WebhookSubscriptionInput - поля подписки при создании и изменении
+type WebhookSubscriptionInput struct {
+	URL      string
+	Secret   string // пусто - сгенерировать (создание) или оставить прежний (изменение)
+	Events   []string
+	IsActive *bool
+}
+
+type WebhookService struct {
+	db     *bun.DB
+	client *http.Client
+}
+
+func NewWebhookService(db *bun.DB) *WebhookService {
+	return &WebhookService{
+		db:     db,
+		client: &http.Client{Timeout: webhookRequestTimeout},
+	}
+}
+
+// CreateSubscription сохраняет подписку. Секрет возвращается только здесь
+// и при изменении, в списках он не отдается.
+func (s *WebhookService) CreateSubscription(ctx context.Context, input *WebhookSubscriptionInput) (*model.WebhookSubscription, error) {
+	if err := validateWebhookInput(input); err != nil {
+		return nil, err
+	}
+
+	sub := &model.WebhookSubscription{
+		URL:      input.URL,
+		Secret:   input.Secret,
+		Events:   input.Events,
+		IsActive: true,
+	}
+	if input.IsActive != nil {
+		sub.IsActive = *input.IsActive
+	}
+
+	if sub.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		sub.Secret = secret
+	}
+
+	if _, err := s.db.NewInsert().Model(sub).Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to save webhook subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	var subs []*model.WebhookSubscription
+	if err := s.db.NewSelect().Model(&subs).Order("id").Scan(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id int64) (*model.WebhookSubscription, error) {
+	sub := new(model.WebhookSubscription)
+	if err := s.db.NewSelect().Model(sub).Where("id = ?", id).Scan(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %d", ErrWebhookNotFound, id)
+	}
+
+	return sub, nil
+}
+
+// UpdateSubscription заменяет адрес и фильтр событий подписки
+func (s *WebhookService) UpdateSubscription(ctx context.Context, id int64, input *WebhookSubscriptionInput) (*model.WebhookSubscription, error) {
+	if err := validateWebhookInput(input); err != nil {
+		return nil, err
+	}
+
+	sub, err := s.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.URL = input.URL
+	sub.Events = input.Events
+	if input.Secret != "" {
+		sub.Secret = input.Secret
+	}
+	if input.IsActive != nil {
+		sub.IsActive = *input.IsActive
+	}
+	sub.UpdatedAt = time.Now()
+
+	_, err = s.db.NewUpdate().
+		Model(sub).
+		Column("url", "secret", "events", "is_active", "updated_at").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+// DeleteSubscription удаляет подписку вместе с журналом доставок
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id int64) error {
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().
+			Model((*model.WebhookDelivery)(nil)).
+			Where("subscription_id = ?", id).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+		}
+
+		res, err := tx.NewDelete().
+			Model((*model.WebhookSubscription)(nil)).
+			Where("id = ?", id).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete webhook subscription: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("%w: %d", ErrWebhookNotFound, id)
+		}
+
+		return nil
+	})
+}
+
+// ListDeliveries возвращает журнал доставок подписки, новые первыми
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID int64, status string, limit int) ([]*model.WebhookDelivery, error) {
+	if _, err := s.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	var deliveries []*model.WebhookDelivery
+	q := s.db.NewSelect().
+		Model(&deliveries).
+		Where("subscription_id = ?", subscriptionID).
+		Order("id DESC").
+		Limit(limit)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ReplayDelivery ставит неуспешную доставку в очередь заново
+// с обнуленным счетчиком попыток
+func (s *WebhookService) ReplayDelivery(ctx context.Context, subscriptionID, deliveryID int64) (*model.WebhookDelivery, error) {
+	delivery := new(model.WebhookDelivery)
+	err := s.db.NewUpdate().
+		Model(delivery).
+		Set("status = ?", DeliveryPending).
+		Set("attempts = 0").
+		Set("next_attempt_at = ?", time.Now()).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", deliveryID).
+		Where("subscription_id = ?", subscriptionID).
+		Where("status = ?", DeliveryFailed).
+		Returning("*").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %d (only failed deliveries can be replayed)", ErrWebhookDeliveryNotFound, deliveryID)
+	}
+
+	return delivery, nil
+}
+
+// ReplayFailed ставит в очередь заново все неуспешные доставки подписки
+func (s *WebhookService) ReplayFailed(ctx context.Context, subscriptionID int64) (int, error) {
+	if _, err := s.GetSubscription(ctx, subscriptionID); err != nil {
+		return 0, err
+	}
+
+	res, err := s.db.NewUpdate().
+		Model((*model.WebhookDelivery)(nil)).
+		Set("status = ?", DeliveryPending).
+		Set("attempts = 0").
+		Set("next_attempt_at = ?", time.Now()).
+		Set("updated_at = ?", time.Now()).
+		Where("subscription_id = ?", subscriptionID).
+		Where("status = ?", DeliveryFailed).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to replay webhook deliveries: %w", err)
+	}
+
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// Emit создает доставки события для активных подписок с подходящим фильтром.
+// db - транзакция, в которой изменено состояние, вызвавшее событие:
+// событие сохраняется только вместе с ним.
+func (s *WebhookService) Emit(ctx context.Context, db bun.IDB, event string, data any) error {
+	var subs []*model.WebhookSubscription
+	err := db.NewSelect().
+		Model(&subs).
+		Where("is_active = ?", true).
+		Where("cardinality(events) = 0 OR ? = ANY(events)", event).
+		Scan(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook subscriptions: %w", err)
+	}
+
+	if len(subs) == 0 {
+		return nil
+	}
+
+	eventID, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(&WebhookEvent{
+		ID:        eventID,
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	deliveries := make([]*model.WebhookDelivery, 0, len(subs))
+	for _, sub := range subs {
+		deliveries = append(deliveries, &model.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        eventID,
+			Event:          event,
+			Payload:        payload,
+			Status:         DeliveryPending,
+			NextAttemptAt:  time.Now(),
+		})
+	}
+
+	if _, err := db.NewInsert().Model(&deliveries).Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// DispatchDeliveries отправляет доставки, время попытки которых наступило.
+// Доставки захватываются через FOR UPDATE SKIP LOCKED со сдвигом
+// next_attempt_at, поэтому несколько экземпляров сервиса не отправляют
+// одну доставку дважды.
+func (s *WebhookService) DispatchDeliveries(ctx context.Context) (int, error) {
+	deliveries, err := s.claimDeliveries(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.deliverAll(ctx, deliveries, func(ctx context.Context, delivery *model.WebhookDelivery) error {
+		_, err := s.db.NewUpdate().
+			Model(delivery).
+			Column("status", "attempts", "next_attempt_at", "response_status", "error", "delivered_at", "updated_at").
+			WherePK().
+			Exec(ctx)
+		return err
+	})
+}
+
+// claimDeliveries захватывает доставки, время попытки которых наступило
+func (s *WebhookService) claimDeliveries(ctx context.Context) ([]*model.WebhookDelivery, error) {
+	now := time.Now()
+	due := s.db.NewSelect().
+		Model((*model.WebhookDelivery)(nil)).
+		Column("id").
+		Where("status = ?", DeliveryPending).
+		Where("next_attempt_at <= ?", now).
+		Order("next_attempt_at").
+		Limit(webhookDispatchBatch).
+		For("UPDATE SKIP LOCKED")
+
+	var ids []int64
+	_, err := s.db.NewUpdate().
+		Model((*model.WebhookDelivery)(nil)).
+		Set("next_attempt_at = ?", now.Add(webhookClaimTTL)).
+		Where("id IN (?)", due).
+		Returning("id").
+		Exec(ctx, &ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var deliveries []*model.WebhookDelivery
+	err = s.db.NewSelect().
+		Model(&deliveries).
+		Relation("Subscription").
+		Where("wd.id IN (?)", bun.In(ids)).
+		Order("wd.id").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// deliverAll выполняет попытки параллельно, не больше webhookDispatchWorkers
+// одновременно: медленный получатель не задерживает остальные подписки.
+// save сохраняет результат попытки.
+func (s *WebhookService) deliverAll(ctx context.Context, deliveries []*model.WebhookDelivery, save func(context.Context, *model.WebhookDelivery) error) (int, error) {
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		errs      []error
+		delivered int
+	)
+
+	sem := make(chan struct{}, webhookDispatchWorkers)
+	for _, delivery := range deliveries {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			s.deliver(ctx, delivery)
+			err := save(ctx, delivery)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if delivery.Status == DeliveryDelivered {
+				delivered++
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("delivery %d: %w", delivery.ID, err))
+			}
+		}()
+	}
+	wg.Wait()
+
+	return delivered, errors.Join(errs...)
+}
+
+// deliver выполняет одну попытку и выставляет статус и время следующей попытки
+func (s *WebhookService) deliver(ctx context.Context, delivery *model.WebhookDelivery) {
+	delivery.Attempts++
+	delivery.UpdatedAt = time.Now()
+
+	// После включения подписки такие доставки можно повторить через replay
+	if !delivery.Subscription.IsActive {
+		delivery.Status = DeliveryFailed
+		delivery.Error = "subscription is disabled"
+		return
+	}
+
+	status, err := s.post(ctx, delivery)
+	delivery.ResponseStatus = status
+	if err == nil {
+		delivery.Status = DeliveryDelivered
+		delivery.DeliveredAt = time.Now()
+		delivery.Error = ""
+		return
+	}
+
+	delivery.Error = err.Error()
+	if delivery.Attempts >= webhookMaxAttempts {
+		delivery.Status = DeliveryFailed
+		return
+	}
+	delivery.NextAttemptAt = time.Now().Add(webhookBackoff(delivery.Attempts))
+}
+
+func (s *WebhookService) post(ctx context.Context, delivery *model.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookHeaderEvent, delivery.Event)
+	req.Header.Set(WebhookHeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, "sha256="+SignWebhook(delivery.Subscription.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// SignWebhook вычисляет подпись запроса: hex(HMAC-SHA256(secret, timestamp + "." + body))
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff - задержка после attempts неудачных попыток: 30s, 1m, 2m, ... до 6h
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= webhookRetryMax {
+			return webhookRetryMax
+		}
+	}
+	return delay
+}
+
+// RunDispatcher периодически вызывает DispatchDeliveries до отмены ctx
+func (s *WebhookService) RunDispatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			delivered, err := s.DispatchDeliveries(ctx)
+			if err != nil {
+				log.Printf("Webhook dispatch finished with errors: %v", err)
+			}
+			if delivered > 0 {
+				log.Printf("Webhook deliveries sent: %d", delivered)
+			}
+		}
+	}
+}
+
+func validateWebhookInput(input *WebhookSubscriptionInput) error {
+	u, err := url.Parse(input.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWebhook)
+	}
+
+	for _, event := range input.Events {
+		if !slices.Contains(WebhookEvents, event) {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+		}
+	}
+
+	// NULL в events не совпал бы ни с одним событием
+	if input.Events == nil {
+		input.Events = []string{}
+	}
+
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	return randomHex(webhookSecretSize)
+}
+
+func randomHex(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"wallet_test/src/modules/wallet/model"
+)
+
+// webhookReceiver - локальный получатель webhook, запоминающий запросы
+type webhookReceiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*receivedWebhook
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func newWebhookReceiver(t *testing.T, status int) (*webhookReceiver, *httptest.Server) {
+	t.Helper()
+
+	r := &webhookReceiver{status: status}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mu.Lock()
+		r.requests = append(r.requests, &receivedWebhook{header: req.Header.Clone(), body: body})
+		status := r.status
+		r.mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return r, srv
+}
+
+func (r *webhookReceiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *webhookReceiver) received() []*receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*receivedWebhook(nil), r.requests...)
+}
+
+func testDelivery(t *testing.T, url string) *model.WebhookDelivery {
+	t.Helper()
+
+	payload, err := json.Marshal(&WebhookEvent{
+		ID:        "event-1",
+		Event:     EventDepositReceived,
+		CreatedAt: time.Now().UTC(),
+		Data:      map[string]string{"amount": "1.5"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &model.WebhookDelivery{
+		ID:             42,
+		SubscriptionID: 7,
+		EventID:        "event-1",
+		Event:          EventDepositReceived,
+		Payload:        payload,
+		Status:         DeliveryPending,
+		NextAttemptAt:  time.Now(),
+		Subscription: &model.WebhookSubscription{
+			ID:       7,
+			URL:      url,
+			Secret:   "test-secret",
+			IsActive: true,
+		},
+	}
+}
+
+// verifySignature проверяет подпись так, как это делает получатель
+func verifySignature(t *testing.T, secret string, req *receivedWebhook) {
+	t.Helper()
+
+	timestamp := req.header.Get(WebhookHeaderTimestamp)
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Fatalf("invalid %s header %q", WebhookHeaderTimestamp, timestamp)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(req.body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := req.header.Get(WebhookHeaderSignature); got != want {
+		t.Fatalf("signature = %q, want %q", got, want)
+	}
+}
+
+func TestDeliverSignsRequest(t *testing.T) {
+	receiver, srv := newWebhookReceiver(t, http.StatusOK)
+	s := NewWebhookService(nil)
+	delivery := testDelivery(t, srv.URL)
+
+	s.deliver(context.Background(), delivery)
+
+	if delivery.Status != DeliveryDelivered || delivery.Attempts != 1 || delivery.ResponseStatus != http.StatusOK {
+		t.Fatalf("delivery = %s, attempts %d, response %d", delivery.Status, delivery.Attempts, delivery.ResponseStatus)
+	}
+	if delivery.DeliveredAt.IsZero() {
+		t.Fatal("delivered_at is not set")
+	}
+
+	requests := receiver.received()
+	if len(requests) != 1 {
+		t.Fatalf("received %d requests, want 1", len(requests))
+	}
+	req := requests[0]
+
+	if got := req.header.Get(WebhookHeaderEvent); got != EventDepositReceived {
+		t.Errorf("%s = %q", WebhookHeaderEvent, got)
+	}
+	if got := req.header.Get(WebhookHeaderDelivery); got != "42" {
+		t.Errorf("%s = %q", WebhookHeaderDelivery, got)
+	}
+	if got := req.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if string(req.body) != string(delivery.Payload) {
+		t.Errorf("body = %s, want %s", req.body, delivery.Payload)
+	}
+	verifySignature(t, "test-secret", req)
+}
+
+func TestDeliverSchedulesRetry(t *testing.T) {
+	receiver, srv := newWebhookReceiver(t, http.StatusInternalServerError)
+	s := NewWebhookService(nil)
+	delivery := testDelivery(t, srv.URL)
+
+	before := time.Now()
+	s.deliver(context.Background(), delivery)
+
+	if delivery.Status != DeliveryPending || delivery.Attempts != 1 {
+		t.Fatalf("delivery = %s, attempts %d, want pending after 1 attempt", delivery.Status, delivery.Attempts)
+	}
+	if delivery.ResponseStatus != http.StatusInternalServerError || delivery.Error == "" {
+		t.Fatalf("response %d, error %q", delivery.ResponseStatus, delivery.Error)
+	}
+	if next := delivery.NextAttemptAt.Sub(before); next < webhookRetryBase || next > webhookRetryBase+time.Second {
+		t.Fatalf("next attempt in %s, want %s", next, webhookRetryBase)
+	}
+
+	// Вторая неудача удваивает задержку
+	before = time.Now()
+	s.deliver(context.Background(), delivery)
+	if next := delivery.NextAttemptAt.Sub(before); next < 2*webhookRetryBase || next > 2*webhookRetryBase+time.Second {
+		t.Fatalf("next attempt in %s, want %s", next, 2*webhookRetryBase)
+	}
+
+	// Последняя попытка переводит доставку в failed
+	delivery.Attempts = webhookMaxAttempts - 1
+	s.deliver(context.Background(), delivery)
+	if delivery.Status != DeliveryFailed || delivery.Attempts != webhookMaxAttempts {
+		t.Fatalf("delivery = %s, attempts %d, want failed", delivery.Status, delivery.Attempts)
+	}
+
+	if n := len(receiver.received()); n != 3 {
+		t.Fatalf("received %d requests, want 3", n)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{9, 128 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, webhookRetryMax},
+		{50, webhookRetryMax},
+	}
+
+	for _, tt := range tests {
+		if got := webhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// Replay сбрасывает failed доставку в pending с нулевым счетчиком попыток:
+// получатель должен получить то же событие с новой подписью
+func TestDeliverReplaySendsSameEvent(t *testing.T) {
+	receiver, srv := newWebhookReceiver(t, http.StatusServiceUnavailable)
+	s := NewWebhookService(nil)
+	delivery := testDelivery(t, srv.URL)
+
+	delivery.Attempts = webhookMaxAttempts - 1
+	s.deliver(context.Background(), delivery)
+	if delivery.Status != DeliveryFailed {
+		t.Fatalf("delivery = %s, want failed", delivery.Status)
+	}
+
+	receiver.setStatus(http.StatusNoContent)
+	delivery.Status = DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+
+	s.deliver(context.Background(), delivery)
+	if delivery.Status != DeliveryDelivered || delivery.Attempts != 1 || delivery.Error != "" {
+		t.Fatalf("delivery = %s, attempts %d, error %q", delivery.Status, delivery.Attempts, delivery.Error)
+	}
+
+	requests := receiver.received()
+	if len(requests) != 2 {
+		t.Fatalf("received %d requests, want 2", len(requests))
+	}
+	for _, req := range requests {
+		var event WebhookEvent
+		if err := json.Unmarshal(req.body, &event); err != nil {
+			t.Fatal(err)
+		}
+		if event.ID != "event-1" || req.header.Get(WebhookHeaderDelivery) != "42" {
+			t.Fatalf("event %q, delivery %q", event.ID, req.header.Get(WebhookHeaderDelivery))
+		}
+		verifySignature(t, "test-secret", req)
+	}
+}
+
+func TestDeliverDisabledSubscription(t *testing.T) {
+	receiver, srv := newWebhookReceiver(t, http.StatusOK)
+	s := NewWebhookService(nil)
+	delivery := testDelivery(t, srv.URL)
+	delivery.Subscription.IsActive = false
+
+	s.deliver(context.Background(), delivery)
+
+	if delivery.Status != DeliveryFailed {
+		t.Fatalf("delivery = %s, want failed", delivery.Status)
+	}
+	if n := len(receiver.received()); n != 0 {
+		t.Fatalf("received %d requests, want 0", n)
+	}
+}
+
+func TestDeliverAllSlowSubscriber(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	})
+
+	_, fast := newWebhookReceiver(t, http.StatusOK)
+
+	s := NewWebhookService(nil)
+	slowDelivery := testDelivery(t, slow.URL)
+	fastDelivery := testDelivery(t, fast.URL)
+	fastDelivery.ID = 43
+
+	saved := make(chan int64, 2)
+	done := make(chan int, 1)
+	go func() {
+		delivered, err := s.deliverAll(context.Background(), []*model.WebhookDelivery{slowDelivery, fastDelivery},
+			func(_ context.Context, d *model.WebhookDelivery) error {
+				saved <- d.ID
+				return nil
+			})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- delivered
+	}()
+
+	// Быстрый получатель не ждет медленного
+	select {
+	case id := <-saved:
+		if id != fastDelivery.ID {
+			t.Fatalf("saved delivery %d first, want %d", id, fastDelivery.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("fast delivery was blocked by the slow subscriber")
+	}
+
+	close(release)
+	if delivered := <-done; delivered != 2 {
+		t.Fatalf("delivered %d, want 2", delivered)
+	}
+}